@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/backtest"
+	"github.com/whichonezhang/traffic_monitor/internal/monitor"
+	"go.uber.org/zap"
+)
+
+// sigmaDetectors are the detectors whose threshold is a number of standard deviations,
+// swept with -sigmas. Other detectors keep their default threshold unless -params sets it.
+var sigmaDetectors = map[string]bool{
+	analyzer.DetectorZScore:       true,
+	analyzer.DetectorSeasonalBand: true,
+	analyzer.DetectorShewhart:     true,
+	analyzer.DetectorEWMAChart:    true,
+}
+
+// detectorSweep is the list of thresholds to try for a detector, nil for its default
+type detectorSweep struct {
+	name       string
+	thresholds []float64
+}
+
+// runBacktest replays a date range through the monitor for every combination of
+// detector thresholds and prints accuracy metrics per configuration
+func runBacktest(args []string) {
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	module := fs.String("module", "", "Module name to replay")
+	idc := fs.String("idc", "", "IDC name to replay")
+	from := fs.String("from", "", "First date to replay (YYYYMMDD)")
+	to := fs.String("to", "", "Last date to replay (YYYYMMDD)")
+	calendars := fs.String("calendars", "", "Calendars of IDCs, e.g. us-west=US,eu-central=EU+CN; other IDCs use CN")
+	labelFile := fs.String("labels", "", "CSV file of labeled anomalies: module,idc,start,end")
+	detectors := fs.String("detectors", "ratio,seasonal-band", "Comma-separated detectors to run")
+	thresholds := fs.String("thresholds", "0.5", "Comma-separated relative change thresholds for the ratio detector")
+	sigmas := fs.String("sigmas", "3", "Comma-separated thresholds in standard deviations for the zscore, seasonal-band, shewhart and ewma-chart detectors")
+	params := fs.String("params", "", "Thresholds of other detectors on their own scale, e.g. cusum-chart=4/5/6,mad=3.5/5")
+	fs.Parse(args)
+
+	if *module == "" || *idc == "" || *from == "" || *to == "" || *labelFile == "" {
+		fmt.Println("Usage: monitor backtest -module=<module> -idc=<idc> -from=<YYYYMMDD> -to=<YYYYMMDD> -labels=<file> [-detectors=<names>] [-thresholds=<list>] [-sigmas=<list>] [-params=<detector>=<list>,...] [-calendars=<idc>=<calendar>,...]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	fromDate, err := time.ParseInLocation("20060102", *from, time.Local)
+	if err != nil {
+		log.Fatalf("Invalid -from date: %v", err)
+	}
+	toDate, err := time.ParseInLocation("20060102", *to, time.Local)
+	if err != nil {
+		log.Fatalf("Invalid -to date: %v", err)
+	}
+	sweeps, err := detectorSweeps(*detectors, *thresholds, *sigmas, *params)
+	if err != nil {
+		log.Fatalf("Invalid detector thresholds: %v", err)
+	}
+	labels, err := backtest.LoadLabels(*labelFile)
+	if err != nil {
+		log.Fatalf("Failed to load labels: %v", err)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, sweep := range sweeps {
+		fmt.Fprintf(writer, "%s\t", strings.ToUpper(sweep.name))
+	}
+	fmt.Fprintln(writer, "DAYS\tSKIPPED\tFAILED\tALERTS\tALERTS/DAY\tLABELS\tSKIPPED LABELS\tDETECTED\tPRECISION\tRECALL\tF1\tMEAN DELAY")
+	reported := make(map[string]bool)
+	for _, configs := range combinations(sweeps) {
+		ratioThreshold := 0.5
+		for _, config := range configs {
+			if threshold, ok := config.Params["threshold"]; ok && config.Name == analyzer.DetectorRatio {
+				ratioThreshold = threshold
+			}
+		}
+		m := monitor.NewMonitor(ratioThreshold, zap.NewNop())
+		if err := setIDCCalendars(m, *calendars); err != nil {
+			log.Fatalf("Invalid -calendars: %v", err)
+		}
+		if err := m.SetDetectors(configs...); err != nil {
+			log.Fatalf("Invalid -detectors: %v", err)
+		}
+
+		result := backtest.Run(backtest.MonitorSource(m, *module, *idc), labels, *module, *idc, fromDate, toDate)
+		// Days missing data fail the same way for every configuration, so each error is logged once
+		for _, dayErr := range result.Errors {
+			message := fmt.Sprintf("%s: %v", dayErr.Date.Format("20060102"), dayErr.Err)
+			if !reported[message] {
+				reported[message] = true
+				log.Printf("Not replayed %s", message)
+			}
+		}
+
+		for _, config := range configs {
+			if threshold, ok := config.Params["threshold"]; ok {
+				fmt.Fprintf(writer, "%.2f\t", threshold)
+			} else {
+				fmt.Fprint(writer, "default\t")
+			}
+		}
+		fmt.Fprintf(writer, "%d\t%d\t%d\t%d\t%.2f\t%d\t%d\t%d\t%.3f\t%.3f\t%.3f\t%s\n",
+			result.Days, result.SkippedDays, result.FailedDays, result.Alerts, result.AlertsPerDay,
+			result.Labels, result.SkippedLabels, result.Detected, result.Precision, result.Recall, result.F1, result.MeanDelay)
+	}
+	writer.Flush()
+}
+
+// detectorSweeps returns the thresholds to try for each detector: -thresholds for the
+// ratio detector, -sigmas for detectors in standard deviations and -params entries of the
+// form name=value/value for any detector, which take precedence
+func detectorSweeps(detectors, thresholds, sigmas, params string) ([]detectorSweep, error) {
+	ratioThresholds, err := parseFloats(thresholds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse -thresholds: %w", err)
+	}
+	sigmaThresholds, err := parseFloats(sigmas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse -sigmas: %w", err)
+	}
+	explicit := make(map[string][]float64)
+	if params != "" {
+		for _, entry := range strings.Split(params, ",") {
+			name, list, found := strings.Cut(entry, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid -params entry %q, expected <detector>=<value>/<value>", entry)
+			}
+			values, err := parseFloats(strings.ReplaceAll(list, "/", ","))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse -params for %s: %w", name, err)
+			}
+			explicit[strings.TrimSpace(name)] = values
+		}
+	}
+
+	var sweeps []detectorSweep
+	for _, name := range strings.Split(detectors, ",") {
+		name = strings.TrimSpace(name)
+		sweep := detectorSweep{name: name}
+		switch {
+		case explicit[name] != nil:
+			sweep.thresholds = explicit[name]
+			delete(explicit, name)
+		case name == analyzer.DetectorRatio:
+			sweep.thresholds = ratioThresholds
+		case sigmaDetectors[name]:
+			sweep.thresholds = sigmaThresholds
+		}
+		sweeps = append(sweeps, sweep)
+	}
+	for name := range explicit {
+		return nil, fmt.Errorf("-params sets %s, which is not in -detectors", name)
+	}
+	return sweeps, nil
+}
+
+// combinations returns the detector configurations of every combination of thresholds
+func combinations(sweeps []detectorSweep) [][]monitor.DetectorConfig {
+	combos := [][]monitor.DetectorConfig{nil}
+	for _, sweep := range sweeps {
+		var next [][]monitor.DetectorConfig
+		for _, combo := range combos {
+			if sweep.thresholds == nil {
+				next = append(next, append(slices.Clone(combo), monitor.DetectorConfig{Name: sweep.name}))
+				continue
+			}
+			for _, threshold := range sweep.thresholds {
+				config := monitor.DetectorConfig{Name: sweep.name, Params: analyzer.DetectorParams{"threshold": threshold}}
+				next = append(next, append(slices.Clone(combo), config))
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// parseFloats parses a comma-separated list of numbers
+func parseFloats(list string) ([]float64, error) {
+	var values []float64
+	for _, field := range strings.Split(list, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/calendar"
+	"github.com/whichonezhang/traffic_monitor/internal/monitor"
+	"go.uber.org/zap"
+)
+
+// runCalendar prints how the monitor sees each date of a range: its lunar date, solar
+// term, festival windows, day type and the baselines it would be compared with, followed
+// by the upcoming festivals
+func runCalendar(args []string) {
+	fs := flag.NewFlagSet("calendar", flag.ExitOnError)
+	date := fs.String("date", "", "Date to inspect (YYYYMMDD, default today)")
+	from := fs.String("from", "", "First date of a range to inspect (YYYYMMDD)")
+	to := fs.String("to", "", "Last date of a range to inspect (YYYYMMDD)")
+	idc := fs.String("idc", "", "IDC whose calendars to use")
+	calendars := fs.String("calendars", "", "Calendars of IDCs, e.g. us-west=US,eu-central=EU+CN; other IDCs use CN")
+	country := fs.String("country", "", "Country of the official holiday schedules in data/holidays, e.g. CN")
+	events := fs.String("events", "", "iCalendar file of events to treat as festivals, e.g. campaigns.ics")
+	upcoming := fs.Int("upcoming", 5, "Number of upcoming festivals to list")
+	fs.Parse(args)
+
+	if *date != "" && (*from != "" || *to != "") || (*from == "") != (*to == "") {
+		fmt.Println("Usage: monitor calendar [-date=<YYYYMMDD> | -from=<YYYYMMDD> -to=<YYYYMMDD>] [-idc=<idc>] [-calendars=<idc>=<calendar>,...] [-country=<country>] [-events=<file.ics>] [-upcoming=<n>]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	fromDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	toDate := fromDate
+	var err error
+	if *date != "" {
+		if fromDate, err = time.ParseInLocation("20060102", *date, time.Local); err != nil {
+			log.Fatalf("Invalid -date: %v", err)
+		}
+		toDate = fromDate
+	}
+	if *from != "" {
+		if fromDate, err = time.ParseInLocation("20060102", *from, time.Local); err != nil {
+			log.Fatalf("Invalid -from date: %v", err)
+		}
+		if toDate, err = time.ParseInLocation("20060102", *to, time.Local); err != nil {
+			log.Fatalf("Invalid -to date: %v", err)
+		}
+	}
+	if toDate.Before(fromDate) {
+		log.Fatalf("-to %s is before -from %s", toDate.Format("2006-01-02"), fromDate.Format("2006-01-02"))
+	}
+
+	m := monitor.NewMonitor(0.5, zap.NewNop())
+	if err := setIDCCalendars(m, *calendars); err != nil {
+		log.Fatalf("Invalid -calendars: %v", err)
+	}
+	if *country != "" {
+		// Baselines can reach back into the previous year; years without a schedule fall
+		// back to festival windows
+		for year := fromDate.Year() - 1; year <= toDate.Year(); year++ {
+			if err := m.LoadHolidaySchedules("data/holidays", *country, year); err != nil {
+				fmt.Fprintf(os.Stderr, "No holiday schedule for %d: %v\n", year, err)
+			}
+		}
+	}
+	if *events != "" {
+		if err := m.LoadEvents(*events, *idc); err != nil {
+			log.Fatalf("Failed to load events: %v", err)
+		}
+	}
+	cal := m.CalendarFor(*idc)
+
+	for day := fromDate; !day.After(toDate); day = day.AddDate(0, 0, 1) {
+		printDate(m, cal, *idc, day)
+	}
+	printUpcoming(cal, fromDate, *upcoming)
+}
+
+// setIDCCalendars applies a -calendars mapping of IDCs to calendar names, e.g.
+// us-west=US,eu-central=EU+CN; an empty mapping leaves every IDC on the lunar calendar
+func setIDCCalendars(m *monitor.Monitor, mapping string) error {
+	if mapping == "" {
+		return nil
+	}
+	for _, entry := range strings.Split(mapping, ",") {
+		idc, names, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || idc == "" || names == "" {
+			return fmt.Errorf("invalid entry %q, expected <idc>=<calendar>[+<calendar>...]", entry)
+		}
+		if err := m.SetIDCCalendarNames(idc, strings.Split(names, "+")...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printDate prints how the monitor sees a date
+func printDate(m *monitor.Monitor, cal calendar.Calendar, idc string, date time.Time) {
+	fmt.Printf("%s %s\n", date.Format("2006-01-02"), date.Weekday())
+	if lunar, err := calendar.ToLunar(date); err == nil {
+		fmt.Printf("  Lunar date: %s\n", lunar)
+	}
+	if term, ok := calendar.GetSolarTerm(date); ok {
+		fmt.Printf("  Solar term: %s\n", calendar.DisplayName(term.Name))
+	}
+	if days := cal.GetFestivalDays(date); len(days) > 0 {
+		names := make([]string, len(days))
+		for i, day := range days {
+			names[i] = calendar.DisplayName(day.String())
+		}
+		fmt.Printf("  Festivals: %s\n", strings.Join(names, ", "))
+	}
+	fmt.Printf("  Day type: %s\n", cal.DayType(date))
+
+	baselines, err := m.BaselineDates(date, idc)
+	if err != nil {
+		fmt.Printf("  Baselines: %v\n\n", err)
+		return
+	}
+	fmt.Println("  Baselines:")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, b := range baselines {
+		fmt.Fprintf(writer, "    %s\t%s %s\t%s\n", b.Period, b.Date.Format("2006-01-02"), b.Date.Weekday(), cal.DayType(b.Date))
+	}
+	writer.Flush()
+	fmt.Println()
+}
+
+// printUpcoming lists the next n festivals from date on, by priority on the same day
+func printUpcoming(cal calendar.Calendar, date time.Time, n int) {
+	if n <= 0 {
+		return
+	}
+	fmt.Println("Upcoming festivals:")
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for next, listed := date, 0; listed < n; {
+		_, festivalDate, err := cal.GetNextFestival(next)
+		if err != nil {
+			break
+		}
+		for _, day := range cal.GetFestivalDays(festivalDate) {
+			if day.Offset != 0 || listed == n {
+				continue
+			}
+			fmt.Fprintf(writer, "  %s\t%s\tin %d days\n", festivalDate.Format("2006-01-02"), calendar.DisplayName(day.Festival),
+				int(festivalDate.Sub(date).Round(24*time.Hour).Hours()/24))
+			listed++
+		}
+		next = festivalDate.AddDate(0, 0, 1)
+	}
+	writer.Flush()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/whichonezhang/traffic_monitor/internal/monitor"
@@ -12,14 +13,32 @@ import (
 )
 
 func main() {
+	// Dispatch subcommands; without one the monitor runs for the current date
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "backtest":
+			runBacktest(os.Args[2:])
+			return
+		case "calendar":
+			runCalendar(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command line flags
 	module := flag.String("module", "", "Module name to monitor")
 	idc := flag.String("idc", "", "IDC name to monitor")
+	idcs := flag.String("idcs", "", "Comma-separated IDCs to monitor together as one module, e.g. us-west,us-east")
 	threshold := flag.Float64("threshold", 0.5, "Threshold for traffic increase (0.5 = 50%)")
+	calendars := flag.String("calendars", "", "Calendars of IDCs, e.g. us-west=US,eu-central=EU+CN; other IDCs use CN")
+	country := flag.String("country", "", "Country of the official holiday schedules in data/holidays, e.g. CN")
+	events := flag.String("events", "", "iCalendar file of events to treat as festivals, e.g. campaigns.ics")
 	flag.Parse()
 
-	if *module == "" || *idc == "" {
-		fmt.Println("Usage: monitor -module=<module> -idc=<idc> [-threshold=<threshold>]")
+	if *module == "" || (*idc == "") == (*idcs == "") {
+		fmt.Println("Usage: monitor -module=<module> (-idc=<idc> | -idcs=<idc>,<idc>...) [-threshold=<threshold>] [-calendars=<idc>=<calendar>,...] [-country=<country>] [-events=<file.ics>]")
+		fmt.Println("       monitor backtest -module=<module> -idc=<idc> -from=<YYYYMMDD> -to=<YYYYMMDD> -labels=<file>")
+		fmt.Println("       monitor calendar [-date=<YYYYMMDD> | -from=<YYYYMMDD> -to=<YYYYMMDD>] [-idc=<idc>] [-calendars=<idc>=<calendar>,...] [-country=<country>] [-events=<file.ics>]")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
@@ -33,10 +52,36 @@ func main() {
 
 	// Create monitor instance
 	m := monitor.NewMonitor(*threshold, logger)
+	if err := setIDCCalendars(m, *calendars); err != nil {
+		logger.Fatal("Invalid -calendars", zap.Error(err))
+	}
 
 	// Run monitoring
 	currentDate := time.Now()
-	if err := m.RunMonitoring(*module, *idc, currentDate); err != nil {
+	if *country != "" {
+		// Baselines can reach back into the previous year; years without a schedule fall
+		// back to festival windows
+		for _, year := range []int{currentDate.Year() - 1, currentDate.Year()} {
+			if err := m.LoadHolidaySchedules("data/holidays", *country, year); err != nil {
+				logger.Warn("No holiday schedule", zap.Int("year", year), zap.Error(err))
+			}
+		}
+	}
+	monitored := []string{*idc}
+	if *idcs != "" {
+		monitored = strings.Split(*idcs, ",")
+	}
+	if *events != "" {
+		if err := m.LoadEvents(*events, monitored...); err != nil {
+			logger.Fatal("Failed to load events", zap.Error(err))
+		}
+	}
+	if *idcs != "" {
+		err = m.RunModuleMonitoring(*module, monitored, currentDate)
+	} else {
+		err = m.RunMonitoring(*module, *idc, currentDate)
+	}
+	if err != nil {
 		logger.Fatal("Monitoring failed", zap.Error(err))
 	}
 
@@ -0,0 +1,546 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+)
+
+// ARIMAOrder describes a seasonal ARIMA(P,D,Q)(SP,SD,SQ)[Period] model
+type ARIMAOrder struct {
+	P, D, Q    int // non-seasonal AR, differencing and MA orders
+	SP, SD, SQ int // seasonal AR, differencing and MA orders
+	Period     int // seasonal period in samples; ignored when SP, SD and SQ are zero
+}
+
+// String returns the conventional notation of the order
+func (o ARIMAOrder) String() string {
+	if !o.seasonal() {
+		return fmt.Sprintf("ARIMA(%d,%d,%d)", o.P, o.D, o.Q)
+	}
+	return fmt.Sprintf("SARIMA(%d,%d,%d)(%d,%d,%d)[%d]", o.P, o.D, o.Q, o.SP, o.SD, o.SQ, o.Period)
+}
+
+func (o ARIMAOrder) seasonal() bool {
+	return o.SP > 0 || o.SD > 0 || o.SQ > 0
+}
+
+func (o ARIMAOrder) validate() error {
+	if o.P < 0 || o.D < 0 || o.Q < 0 || o.SP < 0 || o.SD < 0 || o.SQ < 0 {
+		return fmt.Errorf("invalid order %s: orders must be non-negative", o)
+	}
+	if o.seasonal() && o.Period < 2 {
+		return fmt.Errorf("invalid order %s: seasonal period must be at least 2", o)
+	}
+	return nil
+}
+
+// ARIMAModel is a (seasonal) ARIMA model estimated by conditional sum of squares
+type ARIMAModel struct {
+	Order         ARIMAOrder
+	AR            []float64 // non-seasonal AR coefficients
+	MA            []float64 // non-seasonal MA coefficients
+	SeasonalAR    []float64 // seasonal AR coefficients
+	SeasonalMA    []float64 // seasonal MA coefficients
+	Mean          float64   // mean of the differenced series (zero when differenced)
+	Sigma2        float64   // innovation variance
+	LogLikelihood float64
+	AIC           float64
+
+	history   []float64
+	diffed    []float64
+	skip      []bool // points of diffed whose one-step errors are left out of the fit, nil if none
+	residuals []float64
+	arLags    []lagCoef
+	maLags    []lagCoef
+}
+
+// lagCoef is a single non-zero term of a lag polynomial
+type lagCoef struct {
+	lag  int
+	coef float64
+}
+
+// FitARIMA estimates an ARIMA model of the given order on values
+func FitARIMA(values []float64, order ARIMAOrder) (*ARIMAModel, error) {
+	return fitARIMA(values, nil, order)
+}
+
+// fitARIMA estimates an ARIMA model, leaving the one-step errors at the points marked
+// in imputed, which may be nil, out of the conditional sum of squares
+func fitARIMA(values []float64, imputed []bool, order ARIMAOrder) (*ARIMAModel, error) {
+	if err := order.validate(); err != nil {
+		return nil, err
+	}
+
+	diffed := difference(values, order)
+	maxLag := order.P + order.SP*order.Period
+	numParams := order.P + order.Q + order.SP + order.SQ
+	includeMean := order.D+order.SD == 0
+	if includeMean {
+		numParams++
+	}
+
+	// The differenced point t ends at point t+lost of the original series
+	var skip []bool
+	observed := len(diffed) - maxLag
+	if imputed != nil && len(diffed) > 0 {
+		lost := len(values) - len(diffed)
+		skip = make([]bool, len(diffed))
+		for t := range skip {
+			skip[t] = t+lost < len(imputed) && imputed[t+lost]
+			if skip[t] && t >= maxLag {
+				observed--
+			}
+		}
+	}
+	if observed <= numParams+1 {
+		return nil, fmt.Errorf("insufficient data for %s: %d observed points after differencing", order, observed+maxLag)
+	}
+
+	// Estimate the mean on a standardized scale so one simplex step fits all parameters
+	center := calculateMean(diffed)
+	scale := calculateStdDev(diffed, center)
+	if scale == 0 {
+		scale = 1
+	}
+
+	model := &ARIMAModel{Order: order, history: values, diffed: diffed, skip: skip}
+	objective := func(x []float64) float64 {
+		model.setParams(x, includeMean, center, scale)
+		css, _ := model.conditionalSumOfSquares(false)
+		if math.IsNaN(css) || math.IsInf(css, 0) {
+			return math.MaxFloat64
+		}
+		return css
+	}
+
+	x0 := make([]float64, numParams)
+	best, _ := nelderMead(objective, x0, 0.1, 200*(numParams+1), 1e-10)
+	model.setParams(best, includeMean, center, scale)
+
+	css, count := model.conditionalSumOfSquares(true)
+	model.Sigma2 = css / float64(count)
+	if model.Sigma2 <= 0 {
+		model.Sigma2 = math.SmallestNonzeroFloat64
+	}
+	model.LogLikelihood = -0.5 * float64(count) * (math.Log(2*math.Pi*model.Sigma2) + 1)
+	model.AIC = -2*model.LogLikelihood + 2*float64(numParams+1)
+
+	return model, nil
+}
+
+// setParams maps unconstrained optimizer parameters to stationary and invertible coefficients
+func (m *ARIMAModel) setParams(x []float64, includeMean bool, center, scale float64) {
+	o := m.Order
+	m.AR = constrainStationary(x[:o.P])
+	x = x[o.P:]
+	m.MA = negate(constrainStationary(x[:o.Q]))
+	x = x[o.Q:]
+	m.SeasonalAR = constrainStationary(x[:o.SP])
+	x = x[o.SP:]
+	m.SeasonalMA = negate(constrainStationary(x[:o.SQ]))
+	x = x[o.SQ:]
+
+	m.Mean = 0
+	if includeMean {
+		m.Mean = center + x[0]*scale
+	}
+
+	// Expand (1 - AR(B))(1 - SAR(B^s)) and (1 + MA(B))(1 + SMA(B^s)) into single lag polynomials
+	ar := multiplyPoly(lagPoly(m.AR, 1, -1), lagPoly(m.SeasonalAR, o.Period, -1))
+	ma := multiplyPoly(lagPoly(m.MA, 1, 1), lagPoly(m.SeasonalMA, o.Period, 1))
+	m.arLags = sparseLags(ar, -1)
+	m.maLags = sparseLags(ma, 1)
+}
+
+// conditionalSumOfSquares returns the sum of squared one-step errors and the number of
+// terms. Errors at skipped points are taken as zero.
+func (m *ARIMAModel) conditionalSumOfSquares(keep bool) (float64, int) {
+	w := m.diffed
+	start := 0
+	for _, t := range m.arLags {
+		start = max(start, t.lag)
+	}
+
+	errors := make([]float64, len(w))
+	sum, count := 0.0, 0
+	for t := start; t < len(w); t++ {
+		if t < len(m.skip) && m.skip[t] {
+			continue
+		}
+		e := w[t] - m.Mean
+		for _, ar := range m.arLags {
+			e -= ar.coef * (w[t-ar.lag] - m.Mean)
+		}
+		for _, ma := range m.maLags {
+			if t-ma.lag >= start {
+				e -= ma.coef * errors[t-ma.lag]
+			}
+		}
+		errors[t] = e
+		sum += e * e
+		count++
+	}
+
+	if keep {
+		m.residuals = errors
+	}
+	return sum, count
+}
+
+// Residuals returns the in-sample one-step-ahead errors on the differenced scale
+func (m *ARIMAModel) Residuals() []float64 {
+	return append([]float64(nil), m.residuals...)
+}
+
+// Forecast predicts the next steps values on the original scale
+func (m *ARIMAModel) Forecast(steps int) ([]float64, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("invalid forecast horizon %d", steps)
+	}
+
+	// Forecast the differenced series with future errors set to zero
+	w := append([]float64(nil), m.diffed...)
+	e := append(make([]float64, 0, len(w)+steps), m.residuals...)
+	for h := 0; h < steps; h++ {
+		t := len(w)
+		v := m.Mean
+		for _, ar := range m.arLags {
+			v += ar.coef * (w[t-ar.lag] - m.Mean)
+		}
+		for _, ma := range m.maLags {
+			if t-ma.lag >= 0 {
+				v += ma.coef * e[t-ma.lag]
+			}
+		}
+		w = append(w, v)
+		e = append(e, 0)
+	}
+
+	// Undo differencing: y_t = w_t - sum_k c_k y_{t-k}
+	diffPoly := differencingPoly(m.Order)
+	y := append(make([]float64, 0, len(m.history)+steps), m.history...)
+	forecast := make([]float64, steps)
+	for h := 0; h < steps; h++ {
+		v := w[len(m.diffed)+h]
+		for k := 1; k < len(diffPoly); k++ {
+			v -= diffPoly[k] * y[len(y)-k]
+		}
+		y = append(y, v)
+		forecast[h] = v
+	}
+
+	return forecast, nil
+}
+
+// ForecastStdErr returns the standard error of each forecast step
+func (m *ARIMAModel) ForecastStdErr(steps int) []float64 {
+	// Psi weights of the integrated model phi(B)(1-B)^d(1-B^s)^D y = theta(B) e
+	ar := multiplyPoly(multiplyPoly(lagPoly(m.AR, 1, -1), lagPoly(m.SeasonalAR, m.Order.Period, -1)), differencingPoly(m.Order))
+	ma := multiplyPoly(lagPoly(m.MA, 1, 1), lagPoly(m.SeasonalMA, m.Order.Period, 1))
+
+	psi := make([]float64, steps)
+	stdErr := make([]float64, steps)
+	cumulative := 0.0
+	for j := 0; j < steps; j++ {
+		if j == 0 {
+			psi[j] = 1
+		} else {
+			if j < len(ma) {
+				psi[j] = ma[j]
+			}
+			for k := 1; k <= j && k < len(ar); k++ {
+				psi[j] -= ar[k] * psi[j-k]
+			}
+		}
+		cumulative += psi[j] * psi[j]
+		stdErr[j] = math.Sqrt(m.Sigma2 * cumulative)
+	}
+	return stdErr
+}
+
+// ARIMASearch bounds the orders considered by SelectARIMA
+type ARIMASearch struct {
+	MaxP, MaxQ   int
+	MaxSP, MaxSQ int
+	D, SD        int // differencing orders; negative values select them from the data
+	Period       int // seasonal period; zero disables the seasonal search
+}
+
+// DefaultARIMASearch returns a non-seasonal search over small orders
+func DefaultARIMASearch() ARIMASearch {
+	return ARIMASearch{MaxP: 2, MaxQ: 2, D: -1, SD: -1}
+}
+
+// SelectARIMA fits every order within the search bounds and returns the model with the lowest AIC
+func SelectARIMA(values []float64, search ARIMASearch) (*ARIMAModel, error) {
+	return selectARIMA(values, nil, search)
+}
+
+// selectARIMA implements SelectARIMA, fitting without the points marked in imputed
+func selectARIMA(values []float64, imputed []bool, search ARIMASearch) (*ARIMAModel, error) {
+	period := search.Period
+	seasonalD := search.SD
+	if period < 2 {
+		period, seasonalD = 0, 0
+	} else if seasonalD < 0 {
+		seasonalD = selectSeasonalDifferencing(values, period)
+	}
+	d := search.D
+	if d < 0 {
+		d = selectDifferencing(difference(values, ARIMAOrder{SD: seasonalD, Period: period}))
+	}
+
+	maxSP, maxSQ := search.MaxSP, search.MaxSQ
+	if period == 0 {
+		maxSP, maxSQ = 0, 0
+	}
+
+	var best *ARIMAModel
+	var lastErr error
+	for p := 0; p <= search.MaxP; p++ {
+		for q := 0; q <= search.MaxQ; q++ {
+			for sp := 0; sp <= maxSP; sp++ {
+				for sq := 0; sq <= maxSQ; sq++ {
+					order := ARIMAOrder{P: p, D: d, Q: q, SP: sp, SD: seasonalD, SQ: sq, Period: period}
+					model, err := fitARIMA(values, imputed, order)
+					if err != nil {
+						lastErr = err
+						continue
+					}
+					if best == nil || model.AIC < best.AIC {
+						best = model
+					}
+				}
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no ARIMA model could be fitted: %w", lastErr)
+	}
+	return best, nil
+}
+
+// selectDifferencing picks the differencing order (up to 2) with the lowest standard deviation
+func selectDifferencing(values []float64) int {
+	bestD := 0
+	bestStd := calculateStdDev(values, calculateMean(values))
+	for d := 1; d <= 2; d++ {
+		diffed := difference(values, ARIMAOrder{D: d})
+		std := calculateStdDev(diffed, calculateMean(diffed))
+		if std >= bestStd {
+			break
+		}
+		bestD, bestStd = d, std
+	}
+	return bestD
+}
+
+// selectSeasonalDifferencing applies one seasonal difference when it reduces the variance
+func selectSeasonalDifferencing(values []float64, period int) int {
+	if len(values) <= 2*period {
+		return 0
+	}
+	diffed := difference(values, ARIMAOrder{SD: 1, Period: period})
+	if calculateStdDev(diffed, calculateMean(diffed)) < calculateStdDev(values, calculateMean(values)) {
+		return 1
+	}
+	return 0
+}
+
+// ForecastAccuracy summarizes forecast errors on a holdout sample
+type ForecastAccuracy struct {
+	MAE  float64
+	RMSE float64
+	MAPE float64 // mean absolute percentage error over non-zero actuals
+}
+
+// ForecastComparison compares the simple Forecast method with a fitted ARIMA model
+type ForecastComparison struct {
+	Simple ForecastAccuracy
+	ARIMA  ForecastAccuracy
+	Model  *ARIMAModel
+}
+
+// FitARIMA fits an ARIMA model of the given order to the traffic data on its regular
+// grid, without the one-step errors of imputed points or gaps
+func (a *TimeSeriesAnalyzer) FitARIMA(order ARIMAOrder) (*ARIMAModel, error) {
+	grid, _ := a.onGrid()
+	return fitARIMA(grid.values(), grid.imputed, order)
+}
+
+// SelectARIMA selects the ARIMA model with the lowest AIC for the traffic data on its
+// regular grid, fitted without the one-step errors of imputed points or gaps
+func (a *TimeSeriesAnalyzer) SelectARIMA(search ARIMASearch) (*ARIMAModel, error) {
+	grid, _ := a.onGrid()
+	return selectARIMA(grid.values(), grid.imputed, search)
+}
+
+// CompareForecasts holds out the last holdout points and measures both forecasting
+// methods on them. Imputed points are not trained on or measured against.
+func (a *TimeSeriesAnalyzer) CompareForecasts(holdout int, search ARIMASearch) (*ForecastComparison, error) {
+	if holdout <= 0 || holdout >= len(a.data)-1 {
+		return nil, fmt.Errorf("invalid holdout %d for %d points", holdout, len(a.data))
+	}
+
+	split := len(a.data) - holdout
+	train := &TimeSeriesAnalyzer{data: a.data[:split]}
+	var heldOutImputed []bool
+	if a.imputed != nil {
+		train.imputed = a.imputed[:min(split, len(a.imputed))]
+		heldOutImputed = a.imputed[min(split, len(a.imputed)):]
+	}
+	actual := a.values()[split:]
+
+	simple, err := train.Forecast(holdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run simple forecast: %w", err)
+	}
+
+	model, err := train.SelectARIMA(search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select ARIMA model: %w", err)
+	}
+	fitted, err := model.Forecast(holdout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ARIMA forecast: %w", err)
+	}
+
+	return &ForecastComparison{
+		Simple: forecastAccuracy(actual, simple, heldOutImputed),
+		ARIMA:  forecastAccuracy(actual, fitted, heldOutImputed),
+		Model:  model,
+	}, nil
+}
+
+// forecastAccuracy measures forecast against the actual values not marked in imputed
+func forecastAccuracy(actual, forecast []float64, imputed []bool) ForecastAccuracy {
+	var acc ForecastAccuracy
+	nonZero, count := 0, 0
+	for i := range actual {
+		if i < len(imputed) && imputed[i] {
+			continue
+		}
+		count++
+		diff := actual[i] - forecast[i]
+		acc.MAE += math.Abs(diff)
+		acc.RMSE += diff * diff
+		if actual[i] != 0 {
+			acc.MAPE += math.Abs(diff / actual[i])
+			nonZero++
+		}
+	}
+	if count == 0 {
+		return acc
+	}
+	acc.MAE /= float64(count)
+	acc.RMSE = math.Sqrt(acc.RMSE / float64(count))
+	if nonZero > 0 {
+		acc.MAPE /= float64(nonZero)
+	}
+	return acc
+}
+
+// difference applies d regular and D seasonal differences to values
+func difference(values []float64, order ARIMAOrder) []float64 {
+	out := append([]float64(nil), values...)
+	for i := 0; i < order.D; i++ {
+		out = differenceLag(out, 1)
+	}
+	for i := 0; i < order.SD; i++ {
+		out = differenceLag(out, order.Period)
+	}
+	return out
+}
+
+func differenceLag(values []float64, lag int) []float64 {
+	if len(values) <= lag {
+		return nil
+	}
+	out := make([]float64, len(values)-lag)
+	for i := range out {
+		out[i] = values[i+lag] - values[i]
+	}
+	return out
+}
+
+// differencingPoly returns the coefficients of (1-B)^d (1-B^s)^D
+func differencingPoly(order ARIMAOrder) []float64 {
+	poly := []float64{1}
+	for i := 0; i < order.D; i++ {
+		poly = multiplyPoly(poly, []float64{1, -1})
+	}
+	for i := 0; i < order.SD; i++ {
+		seasonal := make([]float64, order.Period+1)
+		seasonal[0], seasonal[order.Period] = 1, -1
+		poly = multiplyPoly(poly, seasonal)
+	}
+	return poly
+}
+
+// lagPoly builds 1 + sign*sum(coefs[i] B^((i+1)*step))
+func lagPoly(coefs []float64, step int, sign float64) []float64 {
+	poly := make([]float64, len(coefs)*step+1)
+	poly[0] = 1
+	for i, c := range coefs {
+		poly[(i+1)*step] = sign * c
+	}
+	return poly
+}
+
+func multiplyPoly(a, b []float64) []float64 {
+	out := make([]float64, len(a)+len(b)-1)
+	for i, x := range a {
+		if x == 0 {
+			continue
+		}
+		for j, y := range b {
+			out[i+j] += x * y
+		}
+	}
+	return out
+}
+
+// sparseLags returns the non-zero lag terms of poly, rescaled by sign
+func sparseLags(poly []float64, sign float64) []lagCoef {
+	var lags []lagCoef
+	for lag := 1; lag < len(poly); lag++ {
+		if poly[lag] != 0 {
+			lags = append(lags, lagCoef{lag: lag, coef: sign * poly[lag]})
+		}
+	}
+	return lags
+}
+
+// constrainStationary maps unconstrained values to the coefficients of a stationary
+// AR polynomial by treating tanh(x) as partial autocorrelations (Durbin-Levinson)
+func constrainStationary(x []float64) []float64 {
+	phi := make([]float64, len(x))
+	prev := make([]float64, len(x))
+	for k := range x {
+		r := math.Tanh(x[k])
+		copy(prev, phi)
+		for j := 0; j < k; j++ {
+			phi[j] = prev[j] - r*prev[k-1-j]
+		}
+		phi[k] = r
+	}
+	return phi
+}
+
+func negate(values []float64) []float64 {
+	for i := range values {
+		values[i] = -values[i]
+	}
+	return values
+}
+
+// values extracts the request counts of the analyzed data
+func (a *TimeSeriesAnalyzer) values() []float64 {
+	values := make([]float64, len(a.data))
+	for i, d := range a.data {
+		values[i] = d.Requests
+	}
+	return values
+}
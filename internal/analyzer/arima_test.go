@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestFitARIMA(t *testing.T) {
+	// Simulate an AR(1) process with phi = 0.6 around a mean of 50
+	rng := rand.New(rand.NewPCG(1, 2))
+	values := make([]float64, 500)
+	prev := 0.0
+	for i := range values {
+		prev = 0.6*prev + rng.NormFloat64()
+		values[i] = 50 + prev
+	}
+
+	model, err := FitARIMA(values, ARIMAOrder{P: 1})
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.6, model.AR[0], 0.1)
+	assert.InDelta(t, 50, model.Mean, 0.5)
+	assert.InDelta(t, 1, model.Sigma2, 0.2)
+
+	forecast, err := model.Forecast(50)
+	assert.NoError(t, err)
+	assert.Len(t, forecast, 50)
+	// A stationary forecast reverts towards the mean
+	assert.InDelta(t, model.Mean, forecast[49], 0.1)
+
+	stdErr := model.ForecastStdErr(10)
+	assert.Len(t, stdErr, 10)
+	assert.InDelta(t, math.Sqrt(model.Sigma2), stdErr[0], 1e-9)
+	for i := 1; i < len(stdErr); i++ {
+		assert.GreaterOrEqual(t, stdErr[i], stdErr[i-1])
+	}
+}
+
+func TestFitARIMAInvalidOrder(t *testing.T) {
+	_, err := FitARIMA([]float64{1, 2, 3}, ARIMAOrder{P: -1})
+	assert.Error(t, err)
+
+	_, err = FitARIMA([]float64{1, 2, 3}, ARIMAOrder{SP: 1})
+	assert.Error(t, err)
+
+	_, err = FitARIMA([]float64{1, 2, 3}, ARIMAOrder{P: 2, Q: 2})
+	assert.Error(t, err)
+}
+
+func TestSelectARIMASeasonal(t *testing.T) {
+	// Linear trend plus a period-12 cycle: seasonal differencing should be selected
+	rng := rand.New(rand.NewPCG(3, 4))
+	values := make([]float64, 240)
+	for i := range values {
+		values[i] = 100 + 0.5*float64(i) + 20*math.Sin(2*math.Pi*float64(i)/12) + rng.NormFloat64()
+	}
+
+	model, err := SelectARIMA(values, ARIMASearch{MaxP: 1, MaxQ: 1, MaxSP: 1, MaxSQ: 1, D: -1, SD: -1, Period: 12})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, model.Order.SD)
+	assert.Equal(t, 12, model.Order.Period)
+
+	forecast, err := model.Forecast(12)
+	assert.NoError(t, err)
+	for i, v := range forecast {
+		n := float64(len(values) + i)
+		expected := 100 + 0.5*n + 20*math.Sin(2*math.Pi*n/12)
+		assert.InDelta(t, expected, v, 5)
+	}
+}
+
+func TestCompareForecasts(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 300)
+	prev := 0.0
+	rng := rand.New(rand.NewPCG(5, 6))
+	for i := range data {
+		prev = 0.8*prev + rng.NormFloat64()
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  200 + 5*prev,
+		}
+	}
+
+	comparison, err := NewTimeSeriesAnalyzer(data).CompareForecasts(30, DefaultARIMASearch())
+	assert.NoError(t, err)
+	assert.NotNil(t, comparison.Model)
+	assert.Less(t, comparison.ARIMA.RMSE, comparison.Simple.RMSE)
+
+	_, err = NewTimeSeriesAnalyzer(data).CompareForecasts(0, DefaultARIMASearch())
+	assert.Error(t, err)
+}
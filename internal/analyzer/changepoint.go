@@ -0,0 +1,319 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// ChangePointMethod selects the change-point search algorithm
+type ChangePointMethod int
+
+const (
+	// ChangePointPELT finds the optimal segmentation with pruned exact linear time search
+	ChangePointPELT ChangePointMethod = iota
+	// ChangePointBinarySegmentation splits segments greedily at the largest cost reduction
+	ChangePointBinarySegmentation
+	// ChangePointCUSUM raises a change when the cumulative deviation from the current level exceeds a threshold
+	ChangePointCUSUM
+)
+
+// String returns the name of the method
+func (m ChangePointMethod) String() string {
+	switch m {
+	case ChangePointPELT:
+		return "pelt"
+	case ChangePointBinarySegmentation:
+		return "binseg"
+	case ChangePointCUSUM:
+		return "cusum"
+	default:
+		return fmt.Sprintf("ChangePointMethod(%d)", int(m))
+	}
+}
+
+// ChangePointOptions configures DetectChangePoints
+type ChangePointOptions struct {
+	Method ChangePointMethod
+	// Penalty is the cost of adding a change point for PELT and binary segmentation.
+	// Zero uses a BIC-style penalty of 2*sigma^2*ln(n).
+	Penalty float64
+	// MinSegment is the minimum number of points between two change points
+	MinSegment int
+	// MinShift drops shifts whose relative level change is smaller than this fraction
+	MinShift float64
+	// CUSUMThreshold is the CUSUM alarm level in units of sigma
+	CUSUMThreshold float64
+}
+
+// DefaultChangePointOptions returns PELT with segments of at least 30 points and shifts of at least 10%
+func DefaultChangePointOptions() ChangePointOptions {
+	return ChangePointOptions{
+		Method:         ChangePointPELT,
+		MinSegment:     30,
+		MinShift:       0.1,
+		CUSUMThreshold: 5,
+	}
+}
+
+// DetectChangePoints finds persistent shifts in the mean traffic level
+func (a *TimeSeriesAnalyzer) DetectChangePoints(opts ChangePointOptions) ([]types.LevelShift, error) {
+	values := a.values()
+	minSegment := min(max(opts.MinSegment, 1), len(values)/2)
+	if minSegment < 1 {
+		return nil, nil
+	}
+
+	sigma := noiseLevel(a.observed(values))
+	penalty := opts.Penalty
+	if penalty <= 0 {
+		penalty = 2 * sigma * sigma * math.Log(float64(len(values)))
+	}
+
+	var indices []int
+	switch opts.Method {
+	case ChangePointPELT:
+		indices = pelt(values, penalty, minSegment)
+	case ChangePointBinarySegmentation:
+		indices = binarySegmentation(values, penalty, minSegment)
+	case ChangePointCUSUM:
+		threshold := opts.CUSUMThreshold
+		if threshold <= 0 {
+			threshold = 5
+		}
+		indices = cusumChangePoints(values, sigma, threshold, minSegment)
+	default:
+		return nil, fmt.Errorf("unknown change point method %s", opts.Method)
+	}
+
+	return a.levelShifts(values, indices, opts.MinShift), nil
+}
+
+// DetectChangePointsAgainst finds persistent shifts in the traffic level relative to a
+// baseline day, so the daily pattern both share is not taken for shifts. Each point is
+// divided by the baseline at the same time of day and levels are reported on the scale
+// of the baseline mean, e.g. twice the baseline mean for traffic that doubled. Points
+// without a positive baseline point are left out.
+func (a *TimeSeriesAnalyzer) DetectChangePointsAgainst(baseline []types.TrafficData, opts ChangePointOptions) ([]types.LevelShift, error) {
+	baselineAt := make(map[time.Duration]float64, len(baseline))
+	for _, d := range baseline {
+		baselineAt[timeOfDay(d.Timestamp)] = d.Requests
+	}
+	mean := calculateMean(NewTimeSeriesAnalyzer(baseline).values())
+
+	adjusted := &TimeSeriesAnalyzer{}
+	for i, d := range a.data {
+		b, exists := baselineAt[timeOfDay(d.Timestamp)]
+		if !exists || b <= 0 {
+			continue
+		}
+		adjusted.data = append(adjusted.data, types.TrafficData{Timestamp: d.Timestamp, Requests: d.Requests / b * mean})
+		if a.imputed != nil {
+			adjusted.imputed = append(adjusted.imputed, a.isImputed(i))
+		}
+	}
+	return adjusted.DetectChangePoints(opts)
+}
+
+// levelShifts converts change indices to level shifts, merging away shifts below minShift
+func (a *TimeSeriesAnalyzer) levelShifts(values []float64, indices []int, minShift float64) []types.LevelShift {
+	sum := newSegmentCost(values)
+	for len(indices) > 0 {
+		// Find the smallest relative shift and drop it if it is below the limit
+		smallest, smallestChange := -1, math.Inf(1)
+		for i, idx := range indices {
+			before, after := sum.mean(boundary(indices, i-1, 0), idx), sum.mean(idx, boundary(indices, i+1, len(values)))
+			if change := relativeChange(before, after); change < smallestChange {
+				smallest, smallestChange = i, change
+			}
+		}
+		if smallestChange >= minShift {
+			break
+		}
+		indices = append(indices[:smallest], indices[smallest+1:]...)
+	}
+
+	shifts := make([]types.LevelShift, len(indices))
+	for i, idx := range indices {
+		shifts[i] = types.LevelShift{
+			Timestamp: a.data[idx].Timestamp,
+			Before:    sum.mean(boundary(indices, i-1, 0), idx),
+			After:     sum.mean(idx, boundary(indices, i+1, len(values))),
+		}
+	}
+	return shifts
+}
+
+func boundary(indices []int, i, fallback int) int {
+	if i < 0 || i >= len(indices) {
+		return fallback
+	}
+	return indices[i]
+}
+
+func relativeChange(before, after float64) float64 {
+	if before == 0 {
+		if after == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return math.Abs(after-before) / math.Abs(before)
+}
+
+// segmentCost evaluates the squared-error cost of constant-mean segments in O(1)
+type segmentCost struct {
+	sum, sumSquares []float64
+}
+
+func newSegmentCost(values []float64) segmentCost {
+	c := segmentCost{
+		sum:        make([]float64, len(values)+1),
+		sumSquares: make([]float64, len(values)+1),
+	}
+	for i, v := range values {
+		c.sum[i+1] = c.sum[i] + v
+		c.sumSquares[i+1] = c.sumSquares[i] + v*v
+	}
+	return c
+}
+
+// cost returns the sum of squared deviations from the mean of values[start:end]
+func (c segmentCost) cost(start, end int) float64 {
+	n := float64(end - start)
+	s := c.sum[end] - c.sum[start]
+	return c.sumSquares[end] - c.sumSquares[start] - s*s/n
+}
+
+func (c segmentCost) mean(start, end int) float64 {
+	return (c.sum[end] - c.sum[start]) / float64(end-start)
+}
+
+// pelt returns the optimal change indices under a linear penalty (Killick et al. 2012)
+func pelt(values []float64, penalty float64, minSegment int) []int {
+	n := len(values)
+	c := newSegmentCost(values)
+
+	best := make([]float64, n+1)
+	last := make([]int, n+1)
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+	best[0] = -penalty
+
+	candidates := []int{0}
+	for t := minSegment; t <= n; t++ {
+		if s := t - minSegment; s >= minSegment {
+			candidates = append(candidates, s)
+		}
+
+		for _, s := range candidates {
+			if v := best[s] + c.cost(s, t) + penalty; v < best[t] {
+				best[t], last[t] = v, s
+			}
+		}
+
+		// Prune candidates that can never be optimal again
+		kept := candidates[:0]
+		for _, s := range candidates {
+			if best[s]+c.cost(s, t) <= best[t] {
+				kept = append(kept, s)
+			}
+		}
+		candidates = kept
+	}
+
+	var indices []int
+	for t := last[n]; t > 0; t = last[t] {
+		indices = append(indices, t)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// binarySegmentation recursively splits segments while the cost reduction exceeds the penalty
+func binarySegmentation(values []float64, penalty float64, minSegment int) []int {
+	c := newSegmentCost(values)
+
+	var indices []int
+	var split func(start, end int)
+	split = func(start, end int) {
+		bestGain, bestIdx := 0.0, -1
+		total := c.cost(start, end)
+		for i := start + minSegment; i <= end-minSegment; i++ {
+			if gain := total - c.cost(start, i) - c.cost(i, end); gain > bestGain {
+				bestGain, bestIdx = gain, i
+			}
+		}
+		if bestIdx < 0 || bestGain <= penalty {
+			return
+		}
+		indices = append(indices, bestIdx)
+		split(start, bestIdx)
+		split(bestIdx, end)
+	}
+	split(0, len(values))
+
+	sort.Ints(indices)
+	return indices
+}
+
+// cusumChangePoints runs a two-sided tabular CUSUM with reference value sigma/2 and
+// restarts it at each detected change with the level of the following segment
+func cusumChangePoints(values []float64, sigma, threshold float64, minSegment int) []int {
+	var indices []int
+	start := 0
+	for start+minSegment <= len(values) {
+		target := calculateMean(values[start : start+minSegment])
+		high, low := 0.0, 0.0
+		highStart, lowStart := start, start
+		change := -1
+		for t := start; t < len(values); t++ {
+			high = math.Max(0, high+values[t]-target-sigma/2)
+			low = math.Max(0, low+target-values[t]-sigma/2)
+			if high == 0 {
+				highStart = t + 1
+			}
+			if low == 0 {
+				lowStart = t + 1
+			}
+			if high > threshold*sigma {
+				change = highStart
+				break
+			}
+			if low > threshold*sigma {
+				change = lowStart
+				break
+			}
+		}
+		if change < 0 || len(values)-change < minSegment {
+			break
+		}
+		if change-start >= minSegment {
+			indices = append(indices, change)
+		}
+		start = max(change, start+1)
+	}
+	return indices
+}
+
+// noiseLevel estimates the noise standard deviation from the median absolute
+// first difference, which is insensitive to level shifts and outliers
+func noiseLevel(values []float64) float64 {
+	if len(values) < 2 {
+		return 1
+	}
+	diffs := make([]float64, len(values)-1)
+	for i := range diffs {
+		diffs[i] = math.Abs(values[i+1] - values[i])
+	}
+	sort.Float64s(diffs)
+	sigma := diffs[len(diffs)/2] / (0.6745 * math.Sqrt2)
+	if sigma == 0 {
+		sigma = math.Max(1e-3*math.Abs(calculateMean(values)), 1e-9)
+	}
+	return sigma
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestDetectChangePoints(t *testing.T) {
+	// 300 minutes around 100, then a permanent shift to 150 at minute 300
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rng := rand.New(rand.NewPCG(7, 8))
+	data := make([]types.TrafficData, 600)
+	for i := range data {
+		level := 100.0
+		if i >= 300 {
+			level = 150
+		}
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  level + rng.NormFloat64()*5,
+		}
+	}
+	analyzer := NewTimeSeriesAnalyzer(data)
+
+	for _, method := range []ChangePointMethod{ChangePointPELT, ChangePointBinarySegmentation, ChangePointCUSUM} {
+		t.Run(method.String(), func(t *testing.T) {
+			opts := DefaultChangePointOptions()
+			opts.Method = method
+			shifts, err := analyzer.DetectChangePoints(opts)
+			assert.NoError(t, err)
+			if assert.Len(t, shifts, 1) {
+				assert.WithinDuration(t, baseTime.Add(300*time.Minute), shifts[0].Timestamp, 5*time.Minute)
+				assert.InDelta(t, 100, shifts[0].Before, 2)
+				assert.InDelta(t, 150, shifts[0].After, 2)
+			}
+		})
+	}
+}
+
+func TestDetectChangePointsStable(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rng := rand.New(rand.NewPCG(9, 10))
+	data := make([]types.TrafficData, 600)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  100 + rng.NormFloat64()*5,
+		}
+	}
+
+	shifts, err := NewTimeSeriesAnalyzer(data).DetectChangePoints(DefaultChangePointOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, shifts)
+
+	_, err = NewTimeSeriesAnalyzer(data).DetectChangePoints(ChangePointOptions{Method: ChangePointMethod(99), MinSegment: 10})
+	assert.Error(t, err)
+}
+
+func TestDetectChangePointsAgainst(t *testing.T) {
+	// Two days of diurnal traffic; today it rises by 30% from 15:00
+	diurnal := func(day time.Time, seed uint64, shiftFrom int) []types.TrafficData {
+		rng := rand.New(rand.NewPCG(seed, 11))
+		data := make([]types.TrafficData, 1440)
+		for i := range data {
+			requests := 1000 + 500*math.Sin(2*math.Pi*float64(i)/1440) + rng.NormFloat64()*20
+			if shiftFrom > 0 && i >= shiftFrom {
+				requests *= 1.3
+			}
+			data[i] = types.TrafficData{Timestamp: day.Add(time.Duration(i) * time.Minute), Requests: requests}
+		}
+		return data
+	}
+	yesterday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	today := yesterday.AddDate(0, 0, 1)
+	baseline := diurnal(yesterday, 1, 0)
+
+	// The daily pattern alone is a series of shifts in the raw traffic, but none against the baseline
+	clean := NewTimeSeriesAnalyzer(diurnal(today, 2, 0))
+	raw, err := clean.DetectChangePoints(DefaultChangePointOptions())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, raw)
+	shifts, err := clean.DetectChangePointsAgainst(baseline, DefaultChangePointOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, shifts)
+
+	shifts, err = NewTimeSeriesAnalyzer(diurnal(today, 2, 900)).DetectChangePointsAgainst(baseline, DefaultChangePointOptions())
+	assert.NoError(t, err)
+	if assert.Len(t, shifts, 1) {
+		assert.WithinDuration(t, today.Add(15*time.Hour), shifts[0].Timestamp, 5*time.Minute)
+		assert.InDelta(t, 1000, shifts[0].Before, 10)
+		assert.InDelta(t, 1300, shifts[0].After, 10)
+	}
+}
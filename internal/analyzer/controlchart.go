@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"math"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// Names of the control-chart detectors
+const (
+	DetectorShewhart   = "shewhart"
+	DetectorEWMAChart  = "ewma-chart"
+	DetectorCUSUMChart = "cusum-chart"
+)
+
+// Rules reported in types.Anomaly.Rule by the control-chart detectors
+const (
+	RuleWesternElectric1 = "western-electric-1" // one point beyond the control limit
+	RuleWesternElectric2 = "western-electric-2" // two of three points beyond 2 sigma on the same side
+	RuleWesternElectric3 = "western-electric-3" // four of five points beyond 1 sigma on the same side
+	RuleWesternElectric4 = "western-electric-4" // eight points in a row on the same side of the center line
+	RuleEWMALimit        = "ewma-limit"
+	RuleCUSUMUpper       = "cusum-upper"
+	RuleCUSUMLower       = "cusum-lower"
+)
+
+func init() {
+	mustRegisterDetector(DetectorShewhart, func(params DetectorParams) (Detector, error) {
+		return &ShewhartDetector{Threshold: params.Get("threshold", 3), Rules: int(params.Get("rules", 4))}, nil
+	})
+	mustRegisterDetector(DetectorEWMAChart, func(params DetectorParams) (Detector, error) {
+		return &EWMAChartDetector{Lambda: params.Get("lambda", 0.2), Threshold: params.Get("threshold", 3)}, nil
+	})
+	mustRegisterDetector(DetectorCUSUMChart, func(params DetectorParams) (Detector, error) {
+		return &CUSUMChartDetector{K: params.Get("k", 0.5), Threshold: params.Get("threshold", 5)}, nil
+	})
+}
+
+// residualChart charts the residuals from the expected traffic, as the daily pattern
+// would otherwise take the traffic beyond any fixed limits. It returns the expected
+// traffic on the center line and every point's distance from it in sigma. The center is
+// the median residual and sigma comes from the median moving range, so the estimates are
+// not inflated by the shifts and outliers the chart is meant to find.
+func (a *TimeSeriesAnalyzer) residualChart() (expected, z []float64, err error) {
+	expected, residual, err := a.expectedTraffic()
+	if err != nil {
+		return nil, nil, err
+	}
+	observed := a.observed(residual)
+	center, sigma := calculateMedian(observed), noiseLevel(observed)
+	z = make([]float64, len(residual))
+	for i, r := range residual {
+		expected[i] += center
+		z[i] = (r - center) / sigma
+	}
+	return expected, z, nil
+}
+
+// ShewhartDetector is an individuals control chart with the Western Electric rules
+type ShewhartDetector struct {
+	Threshold float64 // control limit of rule 1 in sigma
+	Rules     int     // number of Western Electric rules applied, 1 to 4
+}
+
+// Name returns the detector name
+func (d *ShewhartDetector) Name() string { return DetectorShewhart }
+
+// NeedsBaseline reports that the chart only looks at the current data
+func (d *ShewhartDetector) NeedsBaseline() bool { return false }
+
+// Detect flags points that complete a Western Electric pattern
+func (d *ShewhartDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	if len(a.data) < 2 {
+		return nil, nil
+	}
+	expected, z, err := a.residualChart()
+	if err != nil {
+		return nil, err
+	}
+
+	flagged := make([]bool, len(z))
+	rules := make([]string, len(z))
+	for i := range z {
+		if rule := d.firedRule(z, i); rule != "" {
+			flagged[i], rules[i] = true, rule
+		}
+	}
+	return tagAnomalies(a.flaggedRanges(flagged, rules, expected, z), d.Name()), nil
+}
+
+// firedRule returns the first enabled rule completed by point i, or ""
+func (d *ShewhartDetector) firedRule(z []float64, i int) string {
+	side := math.Copysign(1, z[i])
+	sameSideBeyond := func(window int, limit float64) int {
+		count := 0
+		for j := max(0, i-window+1); j <= i; j++ {
+			if z[j]*side > limit {
+				count++
+			}
+		}
+		return count
+	}
+
+	switch {
+	case math.Abs(z[i]) > d.Threshold:
+		return RuleWesternElectric1
+	case d.Rules >= 2 && math.Abs(z[i]) > 2 && sameSideBeyond(3, 2) >= 2:
+		return RuleWesternElectric2
+	case d.Rules >= 3 && math.Abs(z[i]) > 1 && sameSideBeyond(5, 1) >= 4:
+		return RuleWesternElectric3
+	case d.Rules >= 4 && i >= 7 && sameSideBeyond(8, 0) == 8:
+		return RuleWesternElectric4
+	}
+	return ""
+}
+
+// EWMAChartDetector is an exponentially weighted moving average control chart
+type EWMAChartDetector struct {
+	Lambda    float64 // weight of the newest point, 0 < Lambda <= 1
+	Threshold float64 // control limit width L in sigma of the EWMA statistic
+}
+
+// Name returns the detector name
+func (d *EWMAChartDetector) Name() string { return DetectorEWMAChart }
+
+// NeedsBaseline reports that the chart only looks at the current data
+func (d *EWMAChartDetector) NeedsBaseline() bool { return false }
+
+// Detect flags points where the EWMA statistic leaves its time-varying control limits
+func (d *EWMAChartDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	if len(a.data) < 2 {
+		return nil, nil
+	}
+	expected, z, err := a.residualChart()
+	if err != nil {
+		return nil, err
+	}
+	lambda := math.Min(math.Max(d.Lambda, 1e-3), 1)
+
+	scores := make([]float64, len(z))
+	flagged := make([]bool, len(z))
+	rules := make([]string, len(z))
+	ewma := 0.0
+	for i, v := range z {
+		ewma = lambda*v + (1-lambda)*ewma
+		// Exact variance of the EWMA statistic after i+1 points
+		spread := math.Sqrt(lambda / (2 - lambda) * (1 - math.Pow(1-lambda, float64(2*(i+1)))))
+		scores[i] = ewma / spread
+		if math.Abs(scores[i]) > d.Threshold {
+			flagged[i], rules[i] = true, RuleEWMALimit
+		}
+	}
+	return tagAnomalies(a.flaggedRanges(flagged, rules, expected, scores), d.Name()), nil
+}
+
+// CUSUMChartDetector is a two-sided tabular CUSUM control chart
+type CUSUMChartDetector struct {
+	K         float64 // reference value (allowance) in sigma, typically half the shift to detect
+	Threshold float64 // decision interval H in sigma
+}
+
+// Name returns the detector name
+func (d *CUSUMChartDetector) Name() string { return DetectorCUSUMChart }
+
+// NeedsBaseline reports that the chart only looks at the current data
+func (d *CUSUMChartDetector) NeedsBaseline() bool { return false }
+
+// Detect flags points where either cumulative sum exceeds the decision interval. The sums
+// are not reset after a signal, so a sustained shift is reported as one range.
+func (d *CUSUMChartDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	if len(a.data) < 2 {
+		return nil, nil
+	}
+	expected, z, err := a.residualChart()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(z))
+	flagged := make([]bool, len(z))
+	rules := make([]string, len(z))
+	upper, lower := 0.0, 0.0
+	for i := range z {
+		upper = math.Max(0, upper+z[i]-d.K)
+		lower = math.Max(0, lower-z[i]-d.K)
+		switch {
+		case upper > d.Threshold && upper >= lower:
+			flagged[i], rules[i], scores[i] = true, RuleCUSUMUpper, upper
+		case lower > d.Threshold:
+			flagged[i], rules[i], scores[i] = true, RuleCUSUMLower, -lower
+		}
+	}
+	anomalies := a.flaggedRanges(flagged, rules, expected, scores)
+	for i := range anomalies {
+		anomalies[i].Severity = severityForInterval(anomalies[i].Score, d.Threshold)
+	}
+	return tagAnomalies(anomalies, d.Name()), nil
+}
+
+// severityForInterval grades a CUSUM anomaly by how many decision intervals its
+// cumulative sum reached, as sums grow with the length of a shift rather than its size
+func severityForInterval(score, h float64) types.Severity {
+	if h <= 0 {
+		return severityForScore(score)
+	}
+	switch multiple := math.Abs(score) / h; {
+	case multiple >= 3:
+		return types.SeverityCritical
+	case multiple >= 2:
+		return types.SeverityWarning
+	default:
+		return types.SeverityInfo
+	}
+}
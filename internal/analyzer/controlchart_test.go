@@ -0,0 +1,146 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// shiftedSeries returns 200 noisy points around 100 with shift added from index 120 to 150
+func shiftedSeries(shift float64) []types.TrafficData {
+	rng := rand.New(rand.NewPCG(31, 32))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 200)
+	for i := range data {
+		requests := 100 + rng.NormFloat64()*2
+		if i >= 120 && i < 150 {
+			requests += shift
+		}
+		data[i] = types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+	return data
+}
+
+func TestControlChartsDetectSmallShift(t *testing.T) {
+	// A 1.5 sigma shift is too small for a single point beyond 3 sigma
+	data := shiftedSeries(3)
+	shiftStart := data[120].Timestamp
+	shiftEnd := data[150].Timestamp
+
+	for _, name := range []string{DetectorShewhart, DetectorEWMAChart, DetectorCUSUMChart} {
+		t.Run(name, func(t *testing.T) {
+			detector, err := NewDetector(name, nil)
+			assert.NoError(t, err)
+			assert.False(t, detector.NeedsBaseline())
+
+			anomalies, err := detector.Detect(DetectionInput{Current: data})
+			assert.NoError(t, err)
+
+			found := false
+			for _, a := range anomalies {
+				assert.Equal(t, name, a.Detector)
+				assert.NotEmpty(t, a.Rule)
+				if a.Start.Before(shiftEnd) && shiftStart.Before(a.End) {
+					found = true
+					assert.Greater(t, a.Observed, a.Expected)
+				}
+			}
+			assert.True(t, found, "shift not detected")
+		})
+	}
+}
+
+func TestWesternElectricRules(t *testing.T) {
+	d := &ShewhartDetector{Threshold: 3, Rules: 4}
+	tests := []struct {
+		name string
+		z    []float64
+		want string
+	}{
+		{"beyond limit", []float64{0, 0, -3.5}, RuleWesternElectric1},
+		{"two of three", []float64{2.5, 0, 2.2}, RuleWesternElectric2},
+		{"four of five", []float64{1.5, 1.2, -0.5, 1.1, 1.3}, RuleWesternElectric3},
+		{"eight in a row", []float64{0.2, 0.3, 0.1, 0.5, 0.4, 0.2, 0.6, 0.3}, RuleWesternElectric4},
+		{"in control", []float64{0.2, -0.3, 0.1, 0.5, -0.4, 0.2, 0.6, 0.3}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, d.firedRule(tt.z, len(tt.z)-1))
+		})
+	}
+
+	// Rules beyond the configured count are not applied
+	d.Rules = 1
+	assert.Equal(t, "", d.firedRule([]float64{2.5, 0, 2.2}, 2))
+}
+
+func TestCUSUMChartRuleSide(t *testing.T) {
+	detector := &CUSUMChartDetector{K: 0.5, Threshold: 5}
+	anomalies, err := detector.Detect(DetectionInput{Current: shiftedSeries(-4)})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, anomalies) {
+		assert.Contains(t, anomalies[0].Rule, RuleCUSUMLower)
+		assert.Less(t, anomalies[0].Score, 0.0)
+	}
+}
+
+func TestCUSUMChartSeverity(t *testing.T) {
+	// Severity is graded in multiples of the decision interval, not in sigma
+	tests := []struct {
+		threshold float64
+		want      types.Severity
+	}{
+		{4, types.SeverityCritical},
+		{6, types.SeverityWarning},
+		{10, types.SeverityInfo},
+	}
+	for _, tt := range tests {
+		anomalies, err := (&CUSUMChartDetector{K: 0.5, Threshold: tt.threshold}).Detect(DetectionInput{Current: shiftedSeries(4)})
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, anomalies) {
+			assert.Equal(t, tt.want, anomalies[0].Severity, "H = %v", tt.threshold)
+		}
+	}
+}
+
+func TestControlChartsOnSeasonalTraffic(t *testing.T) {
+	// A clean day of diurnal traffic with noise: the daily pattern is not a shift
+	rng := rand.New(rand.NewPCG(41, 42))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 1440)
+	for i := range data {
+		requests := 1000 + 500*math.Sin(2*math.Pi*float64(i)/1440) + rng.NormFloat64()*20
+		data[i] = types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+
+	// At their default 3-sigma limits the charts keep their nominal rate of a few
+	// false alarms per day of per-minute data, none of them above info
+	for _, name := range []string{DetectorShewhart, DetectorEWMAChart, DetectorCUSUMChart} {
+		detector, err := NewDetector(name, nil)
+		assert.NoError(t, err)
+		anomalies, err := detector.Detect(DetectionInput{Current: data})
+		assert.NoError(t, err)
+		var flagged time.Duration
+		for _, a := range anomalies {
+			flagged += a.End.Sub(a.Start)
+			assert.Equal(t, types.SeverityInfo, a.Severity, name)
+		}
+		assert.Less(t, flagged, 45*time.Minute, name)
+	}
+
+	// With limits for the number of points in a day there are no false alarms
+	for _, detector := range []Detector{
+		&ShewhartDetector{Threshold: 4.5, Rules: 1},
+		&EWMAChartDetector{Lambda: 0.2, Threshold: 4},
+		&CUSUMChartDetector{K: 0.5, Threshold: 10},
+	} {
+		anomalies, err := detector.Detect(DetectionInput{Current: data})
+		assert.NoError(t, err)
+		assert.Empty(t, anomalies, detector.Name())
+	}
+}
@@ -0,0 +1,187 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+const (
+	// antiCorrelationThreshold is the correlation below which two IDCs are reported as anti-correlated
+	antiCorrelationThreshold = -0.5
+	// migrationNetRatio is the largest net ratio of a change still classified as a migration
+	migrationNetRatio = 0.5
+)
+
+// AnalyzeTrafficShift compares the current traffic of every IDC of a module with its
+// baseline, keyed by IDC, and classifies the change of the module as a whole. threshold
+// is the relative change of an IDC or of the module total that counts as significant.
+// An IDC missing from one of the maps counts as having no traffic there. Opposite IDC
+// changes are a migration when they cancel out in the total or when the IDCs are
+// anti-correlated through the day.
+func AnalyzeTrafficShift(current, baseline map[string][]types.TrafficData, threshold float64) (types.TrafficShift, error) {
+	idcSet := make(map[string]bool)
+	for idc := range current {
+		idcSet[idc] = true
+	}
+	for idc := range baseline {
+		idcSet[idc] = true
+	}
+	if len(idcSet) == 0 {
+		return types.TrafficShift{}, fmt.Errorf("no IDC data to analyze")
+	}
+	idcs := make([]string, 0, len(idcSet))
+	for idc := range idcSet {
+		idcs = append(idcs, idc)
+	}
+	sort.Strings(idcs)
+
+	var shift types.TrafficShift
+	var absDelta float64
+	up, down := false, false
+	for _, idc := range idcs {
+		change := idcChange(idc, current[idc], baseline[idc])
+		shift.IDCs = append(shift.IDCs, change)
+		shift.Total.CurrentMean += change.CurrentMean
+		shift.Total.BaselineMean += change.BaselineMean
+		absDelta += math.Abs(change.CurrentMean - change.BaselineMean)
+
+		if significantChange(change, threshold) {
+			if change.CurrentMean > change.BaselineMean {
+				up = true
+			} else {
+				down = true
+			}
+		}
+	}
+	if shift.Total.BaselineMean != 0 {
+		shift.Total.Change = (shift.Total.CurrentMean - shift.Total.BaselineMean) / shift.Total.BaselineMean
+	}
+	if absDelta > 0 {
+		shift.Net = math.Abs(shift.Total.CurrentMean-shift.Total.BaselineMean) / absDelta
+	}
+	shift.AntiCorrelated = antiCorrelatedIDCs(idcs, current, baseline)
+
+	switch {
+	case shift.Total.Change > threshold:
+		shift.Kind = types.ShiftGrowth
+	case shift.Total.Change < -threshold:
+		shift.Kind = types.ShiftDecline
+	case up && down && (shift.Net < migrationNetRatio || movedBetween(shift, threshold)):
+		shift.Kind = types.ShiftMigration
+	case up || down:
+		shift.Kind = types.ShiftLocal
+	default:
+		shift.Kind = types.ShiftNone
+	}
+	return shift, nil
+}
+
+// idcChange compares the mean traffic of one IDC with its baseline
+func idcChange(idc string, current, baseline []types.TrafficData) types.IDCChange {
+	change := types.IDCChange{
+		IDC:          idc,
+		CurrentMean:  calculateMean(NewTimeSeriesAnalyzer(current).values()),
+		BaselineMean: calculateMean(NewTimeSeriesAnalyzer(baseline).values()),
+	}
+	if change.BaselineMean != 0 {
+		change.Change = (change.CurrentMean - change.BaselineMean) / change.BaselineMean
+	}
+	return change
+}
+
+// significantChange reports whether an IDC changed by more than threshold; traffic
+// appearing in an IDC without a baseline is always significant
+func significantChange(change types.IDCChange, threshold float64) bool {
+	if change.BaselineMean == 0 {
+		return change.CurrentMean > 0
+	}
+	return math.Abs(change.Change) > threshold
+}
+
+// movedBetween reports whether an IDC that grew significantly and one that dropped
+// significantly are anti-correlated through the day, i.e. traffic left one for the other
+// at the same time even if other changes keep the net ratio high
+func movedBetween(shift types.TrafficShift, threshold float64) bool {
+	direction := make(map[string]float64, len(shift.IDCs))
+	for _, change := range shift.IDCs {
+		if significantChange(change, threshold) {
+			direction[change.IDC] = math.Copysign(1, change.CurrentMean-change.BaselineMean)
+		}
+	}
+	for _, pair := range shift.AntiCorrelated {
+		if direction[pair.A]*direction[pair.B] < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// antiCorrelatedIDCs returns the IDC pairs whose excess traffic over the baseline is
+// anti-correlated through the day, most anti-correlated first
+func antiCorrelatedIDCs(idcs []string, current, baseline map[string][]types.TrafficData) []types.IDCCorrelation {
+	excess := make(map[string]map[time.Duration]float64, len(idcs))
+	for _, idc := range idcs {
+		excess[idc] = excessByTimeOfDay(current[idc], baseline[idc])
+	}
+
+	var pairs []types.IDCCorrelation
+	for i := 0; i < len(idcs); i++ {
+		for j := i + 1; j < len(idcs); j++ {
+			var x, y []float64
+			for offset, a := range excess[idcs[i]] {
+				if b, exists := excess[idcs[j]][offset]; exists {
+					x = append(x, a)
+					y = append(y, b)
+				}
+			}
+			if coefficient := correlation(x, y); coefficient <= antiCorrelationThreshold {
+				pairs = append(pairs, types.IDCCorrelation{A: idcs[i], B: idcs[j], Coefficient: coefficient})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Coefficient < pairs[j].Coefficient })
+	return pairs
+}
+
+// excessByTimeOfDay returns current minus baseline traffic keyed by the time since midnight
+func excessByTimeOfDay(current, baseline []types.TrafficData) map[time.Duration]float64 {
+	baselineAt := make(map[time.Duration]float64, len(baseline))
+	for _, d := range baseline {
+		baselineAt[timeOfDay(d.Timestamp)] = d.Requests
+	}
+	excess := make(map[time.Duration]float64, len(current))
+	for _, d := range current {
+		offset := timeOfDay(d.Timestamp)
+		if b, exists := baselineAt[offset]; exists {
+			excess[offset] = d.Requests - b
+		}
+	}
+	return excess
+}
+
+func timeOfDay(t time.Time) time.Duration {
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()))
+}
+
+// correlation returns the Pearson correlation of x and y, or 0 if it is undefined
+func correlation(x, y []float64) float64 {
+	if len(x) < 3 || len(x) != len(y) {
+		return 0
+	}
+	meanX, meanY := calculateMean(x), calculateMean(y)
+	var sxy, sxx, syy float64
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
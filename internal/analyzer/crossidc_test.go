@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestAnalyzeTrafficShift(t *testing.T) {
+	rng := rand.New(rand.NewPCG(41, 42))
+	day := func(date time.Time, level func(minute int) float64) []types.TrafficData {
+		data := make([]types.TrafficData, 1440)
+		for i := range data {
+			data[i] = types.TrafficData{Timestamp: date.Add(time.Duration(i) * time.Minute), Requests: level(i) + rng.NormFloat64()*2}
+		}
+		return data
+	}
+	today := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	flat := func(level float64) func(int) float64 { return func(int) float64 { return level } }
+	step := func(before, after float64, at int) func(int) float64 {
+		return func(minute int) float64 {
+			if minute < at {
+				return before
+			}
+			return after
+		}
+	}
+	baseline := map[string][]types.TrafficData{
+		"idc-a": day(yesterday, flat(100)),
+		"idc-b": day(yesterday, flat(100)),
+		"idc-c": day(yesterday, flat(50)),
+	}
+	// A large IDC that keeps the module total steady
+	withD := map[string][]types.TrafficData{"idc-d": day(yesterday, flat(1000))}
+	for idc, data := range baseline {
+		withD[idc] = data
+	}
+
+	tests := []struct {
+		name           string
+		current        map[string][]types.TrafficData
+		baseline       map[string][]types.TrafficData
+		want           types.ShiftKind
+		antiCorrelated bool
+	}{
+		{
+			name: "failover from a to b",
+			current: map[string][]types.TrafficData{
+				// idc-a fails over to idc-b at 08:00
+				"idc-a": day(today, step(100, 5, 480)),
+				"idc-b": day(today, step(100, 195, 480)),
+				"idc-c": day(today, flat(50)),
+			},
+			want:           types.ShiftMigration,
+			antiCorrelated: true,
+		},
+		{
+			name: "failover onto a growing idc",
+			current: map[string][]types.TrafficData{
+				// idc-b takes over idc-a at 08:00 while growing on its own, so the
+				// net ratio is high but both changed at the same time
+				"idc-a": day(today, step(100, 5, 480)),
+				"idc-b": day(today, step(100, 445, 480)),
+				"idc-c": day(today, flat(50)),
+				"idc-d": day(today, flat(1000)),
+			},
+			baseline:       withD,
+			want:           types.ShiftMigration,
+			antiCorrelated: true,
+		},
+		{
+			name: "opposite changes all day",
+			current: map[string][]types.TrafficData{
+				"idc-a": day(today, flat(20)),
+				"idc-b": day(today, flat(400)),
+				"idc-c": day(today, flat(50)),
+				"idc-d": day(today, flat(1000)),
+			},
+			baseline: withD,
+			want:     types.ShiftLocal,
+		},
+		{
+			name: "global growth",
+			current: map[string][]types.TrafficData{
+				"idc-a": day(today, flat(180)),
+				"idc-b": day(today, flat(170)),
+				"idc-c": day(today, flat(90)),
+			},
+			want: types.ShiftGrowth,
+		},
+		{
+			name: "single idc surge",
+			current: map[string][]types.TrafficData{
+				"idc-a": day(today, flat(100)),
+				"idc-b": day(today, flat(100)),
+				"idc-c": day(today, flat(90)),
+			},
+			want: types.ShiftLocal,
+		},
+		{
+			name: "unchanged",
+			current: map[string][]types.TrafficData{
+				"idc-a": day(today, flat(100)),
+				"idc-b": day(today, flat(100)),
+				"idc-c": day(today, flat(50)),
+			},
+			want: types.ShiftNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.baseline == nil {
+				tt.baseline = baseline
+			}
+			shift, err := AnalyzeTrafficShift(tt.current, tt.baseline, 0.5)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, shift.Kind)
+			assert.Len(t, shift.IDCs, len(tt.baseline))
+			assert.Equal(t, "idc-a", shift.IDCs[0].IDC)
+			if tt.antiCorrelated {
+				if assert.Len(t, shift.AntiCorrelated, 1) {
+					assert.Equal(t, "idc-a", shift.AntiCorrelated[0].A)
+					assert.Equal(t, "idc-b", shift.AntiCorrelated[0].B)
+					assert.Less(t, shift.AntiCorrelated[0].Coefficient, -0.9)
+				}
+				assert.Less(t, shift.Total.Change, 0.5)
+			} else {
+				assert.Empty(t, shift.AntiCorrelated)
+			}
+		})
+	}
+
+	_, err := AnalyzeTrafficShift(nil, nil, 0.5)
+	assert.Error(t, err)
+}
@@ -0,0 +1,113 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// DetectionInput is the data a detector evaluates
+type DetectionInput struct {
+	Current         []types.TrafficData
+	Baseline        []types.TrafficData // historical data to compare against, empty for single-series detectors
+	Period          string              // label of the baseline, e.g. "1 day ago"
+	Imputed         []bool              // points of Current filled in by imputation, if any
+	BaselineImputed []bool              // points of Baseline filled in by imputation, if any
+}
+
+// current returns an analyzer over the current data that knows which points were imputed
+func (in DetectionInput) current() *TimeSeriesAnalyzer {
+	a, _ := imputedAnalyzer(in.Current, in.Imputed).onGrid()
+	return a
+}
+
+// baseline returns an analyzer over the baseline data that knows which points were imputed
+func (in DetectionInput) baseline() *TimeSeriesAnalyzer {
+	return imputedAnalyzer(in.Baseline, in.BaselineImputed)
+}
+
+func imputedAnalyzer(data []types.TrafficData, imputed []bool) *TimeSeriesAnalyzer {
+	a := NewTimeSeriesAnalyzer(data)
+	if len(imputed) == len(data) {
+		a.imputed = imputed
+	}
+	return a
+}
+
+// Detector finds anomalies in traffic data
+type Detector interface {
+	// Name returns the name the detector is registered under
+	Name() string
+	// NeedsBaseline reports whether Detect compares the current data with DetectionInput.Baseline
+	NeedsBaseline() bool
+	// Detect returns the anomalies found in the input, tagged with the detector name
+	Detect(input DetectionInput) ([]types.Anomaly, error)
+}
+
+// DetectorParams holds named numeric detector settings. All built-in detectors
+// accept "threshold", e.g. {"threshold": 3}
+type DetectorParams map[string]float64
+
+// Get returns the named parameter, or fallback if it is not set
+func (p DetectorParams) Get(name string, fallback float64) float64 {
+	if v, ok := p[name]; ok {
+		return v
+	}
+	return fallback
+}
+
+// DetectorFactory creates a detector from its parameters
+type DetectorFactory func(params DetectorParams) (Detector, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]DetectorFactory)
+)
+
+// RegisterDetector makes a detector available under name
+func RegisterDetector(name string, factory DetectorFactory) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" || factory == nil {
+		return fmt.Errorf("detector name and factory are required")
+	}
+	if _, exists := registry[name]; exists {
+		return fmt.Errorf("detector %s already registered", name)
+	}
+	registry[name] = factory
+	return nil
+}
+
+// NewDetector creates the registered detector called name
+func NewDetector(name string, params DetectorParams) (Detector, error) {
+	registryMu.RLock()
+	factory, exists := registry[name]
+	registryMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("detector %s not registered", name)
+	}
+	return factory(params)
+}
+
+// DetectorNames returns the names of all registered detectors in sorted order
+func DetectorNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mustRegisterDetector(name string, factory DetectorFactory) {
+	if err := RegisterDetector(name, factory); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,119 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// staticDetector reports a fixed anomaly for registry tests
+type staticDetector struct{}
+
+func (d *staticDetector) Name() string        { return "static" }
+func (d *staticDetector) NeedsBaseline() bool { return false }
+func (d *staticDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	return []types.Anomaly{{Start: input.Current[0].Timestamp, Detector: d.Name()}}, nil
+}
+
+func TestDetectorRegistry(t *testing.T) {
+	names := DetectorNames()
+	for _, name := range []string{DetectorRatio, DetectorZScore, DetectorMAD, DetectorForecastResidual, DetectorSeasonalBand} {
+		assert.Contains(t, names, name)
+	}
+
+	// The registry is global, so the detector may remain registered from a previous run
+	if !slices.Contains(names, "static") {
+		err := RegisterDetector("static", func(DetectorParams) (Detector, error) { return &staticDetector{}, nil })
+		assert.NoError(t, err)
+	}
+	err := RegisterDetector("static", func(DetectorParams) (Detector, error) { return &staticDetector{}, nil })
+	assert.Error(t, err, "duplicate registration should fail")
+
+	detector, err := NewDetector("static", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "static", detector.Name())
+
+	_, err = NewDetector("unknown", nil)
+	assert.Error(t, err)
+}
+
+// flatWithSpike creates per-minute data around 100 with a single spike
+func flatWithSpike(n, spikeAt int, spike float64) []types.TrafficData {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, n)
+	for i := range data {
+		requests := 100.0 + float64(i%7) - 3
+		if i == spikeAt {
+			requests += spike
+		}
+		data[i] = types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+	return data
+}
+
+func TestBuiltinDetectors(t *testing.T) {
+	data := flatWithSpike(300, 150, 200)
+	spike := data[150].Timestamp
+
+	for _, name := range []string{DetectorZScore, DetectorMAD, DetectorForecastResidual, DetectorSeasonalBand} {
+		t.Run(name, func(t *testing.T) {
+			detector, err := NewDetector(name, nil)
+			assert.NoError(t, err)
+			assert.False(t, detector.NeedsBaseline())
+
+			anomalies, err := detector.Detect(DetectionInput{Current: data})
+			assert.NoError(t, err)
+			found := false
+			for _, anomaly := range anomalies {
+				assert.Equal(t, name, anomaly.Detector)
+				if !spike.Before(anomaly.Start) && spike.Before(anomaly.End) {
+					found = true
+				}
+			}
+			assert.True(t, found, "spike should be detected")
+		})
+	}
+}
+
+func TestRatioDetector(t *testing.T) {
+	baseline := flatWithSpike(60, -1, 0)
+	current := make([]types.TrafficData, len(baseline))
+	for i, d := range baseline {
+		current[i] = types.TrafficData{Timestamp: d.Timestamp, Requests: d.Requests * 1.8}
+	}
+
+	detector, err := NewDetector(DetectorRatio, DetectorParams{"threshold": 0.5})
+	assert.NoError(t, err)
+	assert.True(t, detector.NeedsBaseline())
+
+	anomalies, err := detector.Detect(DetectionInput{Current: current, Baseline: baseline})
+	assert.NoError(t, err)
+	if assert.Len(t, anomalies, 1) {
+		assert.InDelta(t, 0.8, anomalies[0].Score, 1e-9)
+		assert.Equal(t, current[0].Timestamp, anomalies[0].Start)
+	}
+
+	// Below the threshold and without a baseline nothing is reported
+	anomalies, err = detector.Detect(DetectionInput{Current: baseline, Baseline: baseline})
+	assert.NoError(t, err)
+	assert.Empty(t, anomalies)
+	anomalies, err = detector.Detect(DetectionInput{Current: current})
+	assert.NoError(t, err)
+	assert.Empty(t, anomalies)
+}
+
+func TestForecastResidualSeasonal(t *testing.T) {
+	// A spike at the daily peak of hourly traffic; the cycle itself is forecast
+	data := generateSeasonalData(24*10, 24, 1)
+	data[150].Requests += 40
+
+	anomalies, err := (&ForecastResidualDetector{Threshold: 4}).Detect(DetectionInput{Current: data})
+	assert.NoError(t, err)
+	if assert.Len(t, anomalies, 1) {
+		assert.Equal(t, data[150].Timestamp, anomalies[0].Start)
+		assert.Equal(t, types.SeverityCritical, anomalies[0].Severity)
+	}
+}
@@ -0,0 +1,212 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// Names of the built-in detectors
+const (
+	DetectorRatio            = "ratio"
+	DetectorZScore           = "zscore"
+	DetectorMAD              = "mad"
+	DetectorForecastResidual = "forecast-residual"
+	DetectorSeasonalBand     = "seasonal-band"
+)
+
+func init() {
+	mustRegisterDetector(DetectorRatio, func(params DetectorParams) (Detector, error) {
+		return &RatioDetector{Threshold: params.Get("threshold", 0.5)}, nil
+	})
+	mustRegisterDetector(DetectorZScore, func(params DetectorParams) (Detector, error) {
+		return &ZScoreDetector{Threshold: params.Get("threshold", 3)}, nil
+	})
+	mustRegisterDetector(DetectorMAD, func(params DetectorParams) (Detector, error) {
+		return &MADDetector{Threshold: params.Get("threshold", 3.5)}, nil
+	})
+	mustRegisterDetector(DetectorForecastResidual, func(params DetectorParams) (Detector, error) {
+		return &ForecastResidualDetector{Threshold: params.Get("threshold", 4)}, nil
+	})
+	mustRegisterDetector(DetectorSeasonalBand, func(params DetectorParams) (Detector, error) {
+		return &SeasonalBandDetector{Threshold: params.Get("threshold", 3)}, nil
+	})
+}
+
+// RatioDetector flags an increase of the mean traffic over the baseline mean
+type RatioDetector struct {
+	Threshold float64 // minimum relative increase, 0.5 = 50%
+}
+
+// Name returns the detector name
+func (d *RatioDetector) Name() string { return DetectorRatio }
+
+// NeedsBaseline reports that the ratio detector compares against a baseline
+func (d *RatioDetector) NeedsBaseline() bool { return true }
+
+// Detect reports the whole current range when its mean exceeds the baseline mean by more than Threshold
+func (d *RatioDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	if len(input.Current) < 2 || len(input.Baseline) == 0 {
+		return nil, nil
+	}
+
+	currentAnalyzer, baselineAnalyzer := input.current(), input.baseline()
+	current := calculateMean(currentAnalyzer.observed(currentAnalyzer.values()))
+	baseline := calculateMean(baselineAnalyzer.observed(baselineAnalyzer.values()))
+	if baseline == 0 {
+		return nil, nil
+	}
+	increase := (current - baseline) / baseline
+	if increase <= d.Threshold {
+		return nil, nil
+	}
+
+	severity := types.SeverityInfo
+	switch {
+	case d.Threshold > 0 && increase >= 3*d.Threshold:
+		severity = types.SeverityCritical
+	case d.Threshold > 0 && increase >= 2*d.Threshold:
+		severity = types.SeverityWarning
+	}
+
+	interval, err := nativeInterval(input.Current)
+	if err != nil {
+		return nil, err
+	}
+	return []types.Anomaly{{
+		Start:    input.Current[0].Timestamp,
+		End:      input.Current[len(input.Current)-1].Timestamp.Add(interval),
+		Observed: current,
+		Expected: baseline,
+		Score:    increase,
+		Severity: severity,
+		Detector: d.Name(),
+	}}, nil
+}
+
+// ZScoreDetector flags points more than Threshold standard deviations from the series mean
+type ZScoreDetector struct {
+	Threshold float64
+}
+
+// Name returns the detector name
+func (d *ZScoreDetector) Name() string { return DetectorZScore }
+
+// NeedsBaseline reports that the z-score detector only looks at the current data
+func (d *ZScoreDetector) NeedsBaseline() bool { return false }
+
+// Detect scores every point against the mean and standard deviation of the series
+func (d *ZScoreDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	values := a.values()
+	mean := calculateMean(a.observed(values))
+	stdDev := calculateStdDev(a.observed(values), mean)
+	return tagAnomalies(a.scoreAgainst(values, constant(mean, len(values)), stdDev, d.Threshold), d.Name()), nil
+}
+
+// MADDetector flags points whose robust z-score (median and MAD) exceeds Threshold
+type MADDetector struct {
+	Threshold float64
+}
+
+// Name returns the detector name
+func (d *MADDetector) Name() string { return DetectorMAD }
+
+// NeedsBaseline reports that the MAD detector only looks at the current data
+func (d *MADDetector) NeedsBaseline() bool { return false }
+
+// Detect scores every point against the median and scaled median absolute deviation
+func (d *MADDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	values := a.values()
+	median := calculateMedian(a.observed(values))
+	return tagAnomalies(a.scoreAgainst(values, constant(median, len(values)), robustStdDev(a.observed(values)), d.Threshold), d.Name()), nil
+}
+
+// ForecastResidualDetector flags points whose one-step-ahead ARIMA forecast error is
+// more than Threshold robust standard deviations. The model is seasonal if the series
+// shows a period, so the daily pattern is not mistaken for forecast errors.
+type ForecastResidualDetector struct {
+	Threshold float64
+}
+
+// Name returns the detector name
+func (d *ForecastResidualDetector) Name() string { return DetectorForecastResidual }
+
+// NeedsBaseline reports that the forecast residual detector only looks at the current data
+func (d *ForecastResidualDetector) NeedsBaseline() bool { return false }
+
+// Detect fits a seasonal ARIMA model and scores its in-sample one-step errors
+func (d *ForecastResidualDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	a := input.current()
+	if len(a.data) < 2 {
+		return nil, nil
+	}
+	model, err := a.SelectSeasonalARIMA()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit forecast model: %w", err)
+	}
+
+	// One-step errors on the differenced scale equal the errors on the original scale
+	values := a.values()
+	residuals := model.Residuals()
+	offset := len(values) - len(residuals)
+	expected := append([]float64(nil), values...)
+	var observedResiduals []float64
+	for i, r := range residuals {
+		expected[offset+i] -= r
+		if !a.isImputed(offset + i) {
+			observedResiduals = append(observedResiduals, r)
+		}
+	}
+	return tagAnomalies(a.scoreAgainst(values, expected, robustStdDev(observedResiduals), d.Threshold), d.Name()), nil
+}
+
+// SeasonalBandDetector flags points outside a band around the seasonal expectation,
+// the same method DetectAnomalies uses with a width of 3
+type SeasonalBandDetector struct {
+	Threshold float64 // band half-width in standard deviations
+}
+
+// Name returns the detector name
+func (d *SeasonalBandDetector) Name() string { return DetectorSeasonalBand }
+
+// NeedsBaseline reports that the seasonal band detector only looks at the current data
+func (d *SeasonalBandDetector) NeedsBaseline() bool { return false }
+
+// Detect flags points outside the seasonal band
+func (d *SeasonalBandDetector) Detect(input DetectionInput) ([]types.Anomaly, error) {
+	anomalies, err := input.current().detectSeasonalBand(d.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	return tagAnomalies(anomalies, d.Name()), nil
+}
+
+// scoreAgainst scores values against expected values and merges points beyond threshold into ranges
+func (a *TimeSeriesAnalyzer) scoreAgainst(values, expected []float64, stdDev, threshold float64) []types.Anomaly {
+	if len(values) < 2 || stdDev == 0 || math.IsNaN(stdDev) {
+		return nil
+	}
+	scores := make([]float64, len(values))
+	for i := range values {
+		scores[i] = (values[i] - expected[i]) / stdDev
+	}
+	return a.anomalyRanges(expected, scores, threshold)
+}
+
+func tagAnomalies(anomalies []types.Anomaly, detector string) []types.Anomaly {
+	for i := range anomalies {
+		anomalies[i].Detector = detector
+	}
+	return anomalies
+}
+
+func constant(value float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
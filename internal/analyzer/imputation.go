@@ -0,0 +1,275 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// ImputationMethod selects how missing points are filled
+type ImputationMethod int
+
+const (
+	// ImputeGap leaves missing points out of the series
+	ImputeGap ImputationMethod = iota
+	// ImputeLinear interpolates linearly between the neighbouring observed points
+	ImputeLinear
+	// ImputeSeasonalNaive copies the value observed at the same time on the previous day,
+	// falling back to linear interpolation where the previous day has no value
+	ImputeSeasonalNaive
+	// ImputeForwardFill repeats the last observed value
+	ImputeForwardFill
+)
+
+// String returns the name of the imputation method
+func (m ImputationMethod) String() string {
+	switch m {
+	case ImputeGap:
+		return "gap"
+	case ImputeLinear:
+		return "linear"
+	case ImputeSeasonalNaive:
+		return "seasonal-naive"
+	case ImputeForwardFill:
+		return "forward-fill"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrNoObservations is returned by Impute for data without a single observed point,
+// e.g. a day lost to an outage
+var ErrNoObservations = errors.New("no observed points to impute from")
+
+// ImputationOptions configures TimeSeriesAnalyzer.Impute
+type ImputationOptions struct {
+	Method        ImputationMethod
+	ZeroIsMissing bool                // treat zero counts, e.g. from collector outages, as missing
+	Interval      time.Duration       // expected spacing of the points, 0 infers it from the data
+	PreviousDay   []types.TrafficData // the previous day's data for ImputeSeasonalNaive
+	// Start and End bound the expected points, e.g. midnight to midnight for a day of
+	// data, so missing points before the first or after the last observation are imputed
+	// too. Points are expected from Start at each interval before End; zero values use
+	// the first and last point of the data.
+	Start, End time.Time
+}
+
+// Impute places the data on a regular grid of the expected interval and fills missing
+// points, and zeros if ZeroIsMissing is set, with the chosen method. Imputed points are
+// kept out of the noise statistics, never reported as anomalies and not used as the
+// starting point of forecasts. With ImputeGap the missing points are dropped instead;
+// seasonal, autocorrelation and ARIMA methods still place the remaining points at their
+// position on the grid.
+func (a *TimeSeriesAnalyzer) Impute(opts ImputationOptions) error {
+	if len(a.data) == 0 {
+		return nil
+	}
+	missing := func(d types.TrafficData) bool {
+		return math.IsNaN(d.Requests) || (opts.ZeroIsMissing && d.Requests == 0)
+	}
+
+	if opts.Method == ImputeGap {
+		kept := make([]types.TrafficData, 0, len(a.data))
+		for _, d := range a.data {
+			if !missing(d) {
+				kept = append(kept, d)
+			}
+		}
+		a.data, a.imputed = kept, nil
+		return nil
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		var err error
+		if interval, err = nativeInterval(a.data); err != nil {
+			return fmt.Errorf("failed to infer data interval: %w", err)
+		}
+	}
+
+	// Place the observed points on the grid; everything else is pending
+	start, end := opts.Start, opts.End
+	if start.IsZero() {
+		start = a.data[0].Timestamp
+	}
+	if end.IsZero() {
+		end = a.data[len(a.data)-1].Timestamp.Add(interval)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("imputation end %s is not after its start %s", end, start)
+	}
+	grid := make([]types.TrafficData, int((end.Sub(start)+interval-1)/interval))
+	pending := make([]bool, len(grid))
+	for i := range grid {
+		grid[i].Timestamp = start.Add(time.Duration(i) * interval)
+		pending[i] = true
+	}
+	observed := 0
+	for _, d := range a.data {
+		offset := d.Timestamp.Sub(start) + interval/2
+		i := int(offset / interval)
+		if offset >= 0 && i < len(grid) && !missing(d) {
+			grid[i].Requests = d.Requests
+			pending[i] = false
+			observed++
+		}
+	}
+	if observed == 0 {
+		return ErrNoObservations
+	}
+	imputed := append([]bool(nil), pending...)
+
+	switch opts.Method {
+	case ImputeLinear:
+		linearFill(grid, pending)
+	case ImputeSeasonalNaive:
+		previous := make(map[time.Duration]float64, len(opts.PreviousDay))
+		for _, d := range opts.PreviousDay {
+			if !missing(d) {
+				previous[timeOfDay(d.Timestamp)] = d.Requests
+			}
+		}
+		for i := range grid {
+			if v, exists := previous[timeOfDay(grid[i].Timestamp)]; pending[i] && exists {
+				grid[i].Requests = v
+				pending[i] = false
+			}
+		}
+		linearFill(grid, pending)
+	case ImputeForwardFill:
+		forwardFill(grid, pending)
+	default:
+		return fmt.Errorf("unknown imputation method %d", opts.Method)
+	}
+
+	a.data, a.imputed = grid, imputed
+	return nil
+}
+
+// Data returns the analyzed data, including imputed points
+func (a *TimeSeriesAnalyzer) Data() []types.TrafficData {
+	return a.data
+}
+
+// Imputed reports for every point of Data whether it was imputed
+func (a *TimeSeriesAnalyzer) Imputed() []bool {
+	imputed := make([]bool, len(a.data))
+	copy(imputed, a.imputed)
+	return imputed
+}
+
+// isImputed reports whether point i was imputed
+func (a *TimeSeriesAnalyzer) isImputed(i int) bool {
+	return i < len(a.imputed) && a.imputed[i]
+}
+
+// observed returns the entries of values, indexed like the data, at points that were not imputed
+func (a *TimeSeriesAnalyzer) observed(values []float64) []float64 {
+	if a.imputed == nil {
+		return values
+	}
+	result := make([]float64, 0, len(values))
+	for i, v := range values {
+		if !a.isImputed(i) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// onGrid returns the data on a regular grid of its native interval together with the
+// grid position of every point, so positional methods such as seasonal indexing and
+// ARIMA lags see gaps, e.g. points dropped by ImputeGap, where they are. Gap positions
+// are interpolated and marked imputed, which keeps them out of statistics and fits.
+// Data without gaps is returned as is with nil positions.
+func (a *TimeSeriesAnalyzer) onGrid() (*TimeSeriesAnalyzer, []int) {
+	interval, err := nativeInterval(a.data)
+	if err != nil {
+		return a, nil
+	}
+	// Round each step, so buckets of 23 or 25 hours across daylight saving changes are one step
+	positions := make([]int, len(a.data))
+	for i := 1; i < len(a.data); i++ {
+		steps := math.Round(float64(a.data[i].Timestamp.Sub(a.data[i-1].Timestamp)) / float64(interval))
+		positions[i] = positions[i-1] + max(int(steps), 1)
+	}
+	size := positions[len(positions)-1] + 1
+	if size == len(a.data) {
+		return a, nil
+	}
+
+	grid := &TimeSeriesAnalyzer{data: make([]types.TrafficData, size), imputed: make([]bool, size)}
+	pending := make([]bool, size)
+	for i := range pending {
+		pending[i] = true
+	}
+	for i, p := range positions {
+		grid.data[p] = a.data[i]
+		grid.imputed[p] = a.isImputed(i)
+		pending[p] = false
+	}
+	for p := range grid.data {
+		if pending[p] {
+			grid.data[p].Timestamp = grid.data[p-1].Timestamp.Add(interval)
+			grid.imputed[p] = true
+		}
+	}
+	linearFill(grid.data, pending)
+	return grid, positions
+}
+
+// atPositions returns the entries of values at the given grid positions
+func atPositions(values []float64, positions []int) []float64 {
+	if values == nil {
+		return nil
+	}
+	result := make([]float64, len(positions))
+	for i, p := range positions {
+		result[i] = values[p]
+	}
+	return result
+}
+
+// linearFill interpolates pending points between their observed neighbours; pending
+// points before the first or after the last observed point take its value
+func linearFill(data []types.TrafficData, pending []bool) {
+	previous := -1
+	for i := 0; i <= len(data); i++ {
+		if i < len(data) && pending[i] {
+			continue
+		}
+		// Fill the run of pending points between previous and i
+		for j := previous + 1; j < i; j++ {
+			switch {
+			case previous < 0 && i < len(data):
+				data[j].Requests = data[i].Requests
+			case i == len(data) && previous >= 0:
+				data[j].Requests = data[previous].Requests
+			case previous >= 0:
+				weight := float64(j-previous) / float64(i-previous)
+				data[j].Requests = data[previous].Requests + weight*(data[i].Requests-data[previous].Requests)
+			}
+			pending[j] = false
+		}
+		previous = i
+	}
+}
+
+// forwardFill repeats the last observed value; leading pending points take the first observed value
+func forwardFill(data []types.TrafficData, pending []bool) {
+	last := math.NaN()
+	for i := range data {
+		if !pending[i] {
+			last = data[i].Requests
+			continue
+		}
+		if !math.IsNaN(last) {
+			data[i].Requests = last
+			pending[i] = false
+		}
+	}
+	linearFill(data, pending)
+}
@@ -0,0 +1,199 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// outageSeries returns ten minutes of traffic 10, 20, ..., 100 where minute 3 is
+// missing and minutes 5 and 6 report zero
+func outageSeries(start time.Time) []types.TrafficData {
+	var data []types.TrafficData
+	for i := 0; i < 10; i++ {
+		requests := float64(10 * (i + 1))
+		switch i {
+		case 3:
+			continue
+		case 5, 6:
+			requests = 0
+		}
+		data = append(data, types.TrafficData{Timestamp: start.Add(time.Duration(i) * time.Minute), Requests: requests})
+	}
+	return data
+}
+
+func TestImpute(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	previousDay := make([]types.TrafficData, 10)
+	for i := range previousDay {
+		previousDay[i] = types.TrafficData{Timestamp: start.AddDate(0, 0, -1).Add(time.Duration(i) * time.Minute), Requests: 1}
+	}
+	imputedPoints := []bool{false, false, false, true, false, true, true, false, false, false}
+
+	tests := []struct {
+		name string
+		opts ImputationOptions
+		want []float64
+	}{
+		{"linear", ImputationOptions{Method: ImputeLinear, ZeroIsMissing: true}, []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}},
+		{"forward fill", ImputationOptions{Method: ImputeForwardFill, ZeroIsMissing: true}, []float64{10, 20, 30, 30, 50, 50, 50, 80, 90, 100}},
+		{"seasonal naive", ImputationOptions{Method: ImputeSeasonalNaive, ZeroIsMissing: true, PreviousDay: previousDay}, []float64{10, 20, 30, 1, 50, 1, 1, 80, 90, 100}},
+		{"seasonal naive without previous day", ImputationOptions{Method: ImputeSeasonalNaive, ZeroIsMissing: true}, []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewTimeSeriesAnalyzer(outageSeries(start))
+			assert.NoError(t, a.Impute(tt.opts))
+			assert.Equal(t, tt.want, a.values())
+			assert.Equal(t, imputedPoints, a.Imputed())
+			assert.Equal(t, start.Add(3*time.Minute), a.Data()[3].Timestamp)
+		})
+	}
+
+	// Zeros are kept as observations unless they are declared missing
+	a := NewTimeSeriesAnalyzer(outageSeries(start))
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeLinear}))
+	assert.Equal(t, []float64{10, 20, 30, 40, 50, 0, 0, 80, 90, 100}, a.values())
+
+	// Leaving gaps drops the missing points
+	a = NewTimeSeriesAnalyzer(outageSeries(start))
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeGap, ZeroIsMissing: true}))
+	assert.Equal(t, []float64{10, 20, 30, 50, 80, 90, 100}, a.values())
+	assert.Equal(t, make([]bool, 7), a.Imputed())
+
+	// Missing points at either end of the expected range are imputed too
+	a = NewTimeSeriesAnalyzer(outageSeries(start)[1:8])
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeLinear, ZeroIsMissing: true, Start: start, End: start.Add(10 * time.Minute)}))
+	assert.Equal(t, []float64{20, 20, 30, 40, 50, 60, 70, 80, 90, 90}, a.values())
+	assert.Equal(t, []bool{true, false, false, true, false, true, true, false, false, true}, a.Imputed())
+	assert.Equal(t, start, a.Data()[0].Timestamp)
+	assert.Error(t, a.Impute(ImputationOptions{Method: ImputeLinear, Start: start, End: start}))
+
+	a = NewTimeSeriesAnalyzer([]types.TrafficData{{Timestamp: start}, {Timestamp: start.Add(time.Minute)}})
+	assert.Error(t, a.Impute(ImputationOptions{Method: ImputeLinear, ZeroIsMissing: true}))
+}
+
+func TestGapsKeepPositions(t *testing.T) {
+	// A repeating pattern of 4 points with single points dropped, as ImputeGap leaves it
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	pattern := []float64{-30, -10, 10, 30}
+	data := make([]types.TrafficData, 48)
+	for i := range data {
+		requests := 100 + pattern[i%4]
+		if i == 5 || i == 22 {
+			requests = 0
+		}
+		data[i] = types.TrafficData{Timestamp: start.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+	a := NewTimeSeriesAnalyzer(data)
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeGap, ZeroIsMissing: true}))
+	assert.Len(t, a.Data(), 46)
+
+	seasonality, err := a.CalculateSeasonality(4)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{70, 90, 110, 130}, seasonality, 1e-9)
+
+	_, seasonal, _, err := a.DecomposePeriod(4)
+	assert.NoError(t, err)
+	if assert.Len(t, seasonal, 46) {
+		// Points after the gaps keep their phase
+		assert.InDelta(t, pattern[6%4], seasonal[5], 2)
+		assert.InDelta(t, pattern[47%4], seasonal[45], 2)
+	}
+
+	periods, err := a.DetectSeasonality(12)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, periods) {
+		assert.Equal(t, 4, periods[0].Period)
+	}
+}
+
+func TestImputedPointsAreNotAnomalies(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 120)
+	for i := range data {
+		requests := 100.0 + float64(i%5)
+		if i >= 60 && i < 70 {
+			// Collector outage
+			requests = 0
+		}
+		data[i] = types.TrafficData{Timestamp: start.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+	// The outage ends the day, so the forecast must not extrapolate from it
+	data = append(data, types.TrafficData{Timestamp: start.Add(120 * time.Minute), Requests: 0})
+
+	raw, err := (&ZScoreDetector{Threshold: 3}).Detect(DetectionInput{Current: data})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, raw)
+
+	a := NewTimeSeriesAnalyzer(data)
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeForwardFill, ZeroIsMissing: true}))
+	for _, name := range []string{DetectorZScore, DetectorMAD, DetectorSeasonalBand, DetectorShewhart} {
+		detector, err := NewDetector(name, nil)
+		assert.NoError(t, err)
+		anomalies, err := detector.Detect(DetectionInput{Current: a.Data(), Imputed: a.Imputed()})
+		assert.NoError(t, err)
+		for _, anomaly := range anomalies {
+			assert.False(t, anomaly.Start.Before(start.Add(70*time.Minute)) && anomaly.End.After(start.Add(60*time.Minute)),
+				"%s flagged the imputed outage", name)
+		}
+	}
+
+	forecast, err := a.Forecast(3)
+	assert.NoError(t, err)
+	if assert.Len(t, forecast, 3) {
+		assert.InDelta(t, 100, forecast[0], 10)
+	}
+}
+
+func TestImputedPointsInModels(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	// Three hours of rising traffic followed by an hour-long outage
+	data := make([]types.TrafficData, 240)
+	for i := range data {
+		requests := float64(100 * (i/60 + 1))
+		if i >= 180 {
+			requests = 0
+		}
+		data[i] = types.TrafficData{Timestamp: start.Add(time.Duration(i) * time.Minute), Requests: requests}
+	}
+	a := NewTimeSeriesAnalyzer(data)
+	assert.NoError(t, a.Impute(ImputationOptions{Method: ImputeForwardFill, ZeroIsMissing: true}))
+
+	// Buckets are imputed only if all of their points are
+	hourly, err := a.ResampleWith(ResampleOptions{Interval: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, false, false, true}, hourly.Imputed())
+	twoHours, err := a.ResampleWith(ResampleOptions{Interval: 2 * time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false, false}, twoHours.Imputed())
+
+	// The forecast continues the trend across the outage instead of starting from its filled-in hour
+	forecast, err := a.ForecastAt(time.Hour, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, forecast, 1) {
+		assert.Equal(t, start.Add(4*time.Hour), forecast[0].Timestamp)
+		assert.InDelta(t, 25140, forecast[0].Value, 1e-6)
+	}
+
+	// Imputed points take no part in the autocorrelation or the fit
+	values := make([]float64, 48)
+	imputed := make([]bool, len(values))
+	for i := range values {
+		values[i] = []float64{1, 3, 2, 0}[i%4]
+		if i%9 == 5 {
+			values[i], imputed[i] = 50, true
+		}
+	}
+	assert.Less(t, autocorrelation(values, nil, 4), 0.5)
+	assert.InDelta(t, 1, autocorrelation(values, imputed, 4), 0.2)
+	unmasked, err := FitARIMA(values, ARIMAOrder{P: 1})
+	assert.NoError(t, err)
+	masked, err := fitARIMA(values, imputed, ARIMAOrder{P: 1})
+	assert.NoError(t, err)
+	assert.Less(t, masked.Sigma2, unmasked.Sigma2)
+}
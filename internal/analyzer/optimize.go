@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+)
+
+// nelderMead minimizes f starting from x0 using the Nelder-Mead simplex method.
+// It returns the best point found and its objective value.
+func nelderMead(f func([]float64) float64, x0 []float64, step float64, maxIter int, tol float64) ([]float64, float64) {
+	n := len(x0)
+	if n == 0 {
+		return nil, f(nil)
+	}
+
+	// Build the initial simplex around x0
+	simplex := make([][]float64, n+1)
+	values := make([]float64, n+1)
+	for i := range simplex {
+		simplex[i] = append([]float64(nil), x0...)
+		if i > 0 {
+			simplex[i][i-1] += step
+		}
+		values[i] = f(simplex[i])
+	}
+
+	order := make([]int, n+1)
+	centroid := make([]float64, n)
+	point := func(from []float64, coef float64) []float64 {
+		p := make([]float64, n)
+		for j := range p {
+			p[j] = centroid[j] + coef*(from[j]-centroid[j])
+		}
+		return p
+	}
+
+	for iter := 0; iter < maxIter; iter++ {
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+		best, worst, second := order[0], order[n], order[n-1]
+
+		if math.Abs(values[worst]-values[best]) <= tol*(math.Abs(values[best])+tol) {
+			break
+		}
+
+		// Centroid of all points except the worst
+		for j := range centroid {
+			centroid[j] = 0
+		}
+		for _, i := range order[:n] {
+			for j := range centroid {
+				centroid[j] += simplex[i][j] / float64(n)
+			}
+		}
+
+		reflected := point(simplex[worst], -1)
+		fr := f(reflected)
+		switch {
+		case fr < values[best]:
+			expanded := point(simplex[worst], -2)
+			if fe := f(expanded); fe < fr {
+				simplex[worst], values[worst] = expanded, fe
+			} else {
+				simplex[worst], values[worst] = reflected, fr
+			}
+		case fr < values[second]:
+			simplex[worst], values[worst] = reflected, fr
+		default:
+			contracted := point(simplex[worst], 0.5)
+			if fr < values[worst] {
+				contracted = point(simplex[worst], -0.5)
+			}
+			if fc := f(contracted); fc < math.Min(fr, values[worst]) {
+				simplex[worst], values[worst] = contracted, fc
+				continue
+			}
+			// Shrink towards the best point
+			for _, i := range order[1:] {
+				for j := range simplex[i] {
+					simplex[i][j] = simplex[best][j] + 0.5*(simplex[i][j]-simplex[best][j])
+				}
+				values[i] = f(simplex[i])
+			}
+		}
+	}
+
+	best := 0
+	for i := range values {
+		if values[i] < values[best] {
+			best = i
+		}
+	}
+	return simplex[best], values[best]
+}
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// Aggregator combines the values of one resampling bucket
+type Aggregator func(values []float64) float64
+
+// AggregateSum totals the bucket, e.g. requests per hour
+func AggregateSum(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// AggregateMean averages the bucket, e.g. requests per minute over an hour
+func AggregateMean(values []float64) float64 { return calculateMean(values) }
+
+// AggregateMax returns the largest value of the bucket
+func AggregateMax(values []float64) float64 { return slices.Max(values) }
+
+// AggregateMin returns the smallest value of the bucket
+func AggregateMin(values []float64) float64 { return slices.Min(values) }
+
+// AggregatePercentile returns an aggregator for the p-th percentile (0-100) of the
+// bucket, interpolating linearly between the closest ranks
+func AggregatePercentile(p float64) Aggregator {
+	return func(values []float64) float64 {
+		sorted := append([]float64(nil), values...)
+		sort.Float64s(sorted)
+		rank := math.Min(math.Max(p, 0), 100) / 100 * float64(len(sorted)-1)
+		lower := int(rank)
+		if lower+1 >= len(sorted) {
+			return sorted[len(sorted)-1]
+		}
+		return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+	}
+}
+
+// ResampleOptions configures ResampleWith
+type ResampleOptions struct {
+	Interval  time.Duration
+	Aggregate Aggregator     // combines each bucket, AggregateSum if nil
+	Location  *time.Location // buckets align to midnight in this location, the data's location if nil
+	// MinCoverage is the share of the native points a bucket must hold to be kept, 1 if
+	// zero. With the default sum, partially covered buckets are scaled up to the full interval.
+	MinCoverage float64
+}
+
+// Resample sums data into consecutive buckets of the given interval, aligned to the
+// start of the first point's day. Buckets with fewer points than the interval
+// holds at the native resolution are dropped, so a partial trailing hour is not
+// mistaken for a drop in traffic.
+func Resample(data []types.TrafficData, interval time.Duration) ([]types.TrafficData, error) {
+	return ResampleWith(data, ResampleOptions{Interval: interval})
+}
+
+// ResampleWith aggregates data into buckets of opts.Interval aligned to midnight in
+// opts.Location and counted in local time, so hourly buckets start on the hour on
+// daylight saving days too. Buckets without data are left out, as are buckets covering less
+// than MinCoverage of their native points, so gaps stay gaps instead of looking
+// like drops in traffic.
+func ResampleWith(data []types.TrafficData, opts ResampleOptions) ([]types.TrafficData, error) {
+	resampled, _, err := resample(data, nil, opts)
+	return resampled, err
+}
+
+// ResampleWith returns an analyzer over the data resampled with opts. A bucket is
+// imputed if all of its points were imputed.
+func (a *TimeSeriesAnalyzer) ResampleWith(opts ResampleOptions) (*TimeSeriesAnalyzer, error) {
+	data, imputed, err := resample(a.data, a.imputed, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &TimeSeriesAnalyzer{data: data, imputed: imputed}, nil
+}
+
+// resample implements ResampleWith, also returning which buckets hold only imputed
+// points if imputed is not nil
+func resample(data []types.TrafficData, imputed []bool, opts ResampleOptions) ([]types.TrafficData, []bool, error) {
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
+	native, err := nativeInterval(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	interval := opts.Interval
+	if interval < native || interval%native != 0 {
+		return nil, nil, fmt.Errorf("interval %s is not a multiple of the data resolution %s", interval, native)
+	}
+	aggregate := opts.Aggregate
+	if aggregate == nil {
+		aggregate = AggregateSum
+	}
+	location := opts.Location
+	if location == nil {
+		location = data[0].Timestamp.Location()
+	}
+	coverage := opts.MinCoverage
+	if coverage <= 0 || coverage > 1 {
+		coverage = 1
+	}
+
+	first := wallClock(data[0].Timestamp.In(location))
+	origin := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	expected := int(interval / native)
+	partialSums := opts.Aggregate == nil
+
+	var result []types.TrafficData
+	var resultImputed []bool
+	var bucket []float64
+	bucketImputed := true
+	flush := func() {
+		if len(result) == 0 {
+			return
+		}
+		if float64(len(bucket)) < coverage*float64(expected) {
+			result = result[:len(result)-1]
+		} else {
+			if imputed != nil {
+				resultImputed = append(resultImputed, bucketImputed)
+			}
+			value := aggregate(bucket)
+			if partialSums && len(bucket) < expected {
+				value *= float64(expected) / float64(len(bucket))
+			}
+			result[len(result)-1].Requests = value
+		}
+		bucket, bucketImputed = bucket[:0], true
+	}
+
+	for i, d := range data {
+		start := bucketStart(d.Timestamp.In(location), origin, interval)
+		if len(result) == 0 || !result[len(result)-1].Timestamp.Equal(start) {
+			flush()
+			result = append(result, types.TrafficData{Timestamp: start})
+		}
+		bucket = append(bucket, d.Requests)
+		bucketImputed = bucketImputed && i < len(imputed) && imputed[i]
+	}
+	flush()
+	return result, resultImputed, nil
+}
+
+// bucketStart returns the start of the bucket holding t, counting intervals in wall-clock
+// time from origin, so buckets stay on the same local hours across daylight saving changes
+func bucketStart(t, origin time.Time, interval time.Duration) time.Time {
+	wall := wallClock(t)
+	startWall := origin.Add(wall.Sub(origin) / interval * interval)
+	// Prefer the offset of t, which tells the two passes of a repeated hour apart
+	_, offset := t.Zone()
+	if start := startWall.Add(-time.Duration(offset) * time.Second).In(t.Location()); wallClock(start).Equal(startWall) {
+		return start
+	}
+	return time.Date(startWall.Year(), startWall.Month(), startWall.Day(), startWall.Hour(), startWall.Minute(),
+		startWall.Second(), startWall.Nanosecond(), t.Location())
+}
+
+// wallClock returns the local date and time of t as the same reading in UTC
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// nativeInterval returns the smallest spacing between consecutive data points, which
+// is the data resolution even when some points are missing
+func nativeInterval(data []types.TrafficData) (time.Duration, error) {
+	if len(data) < 2 {
+		return 0, fmt.Errorf("at least two points are needed to infer the data resolution")
+	}
+	var interval time.Duration
+	for i := 1; i < len(data); i++ {
+		spacing := data[i].Timestamp.Sub(data[i-1].Timestamp)
+		if spacing <= 0 {
+			return 0, fmt.Errorf("data timestamps are not increasing")
+		}
+		if interval == 0 || spacing < interval {
+			interval = spacing
+		}
+	}
+	return interval, nil
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestResampleWith(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	// Two hours of per-minute data starting at 22:00 UTC, 06:00 in UTC+8, with minute 15 missing
+	start := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+	var data []types.TrafficData
+	for i := 0; i < 120; i++ {
+		if i == 15 {
+			continue
+		}
+		data = append(data, types.TrafficData{Timestamp: start.Add(time.Duration(i) * time.Minute), Requests: float64(i % 60)})
+	}
+
+	tests := []struct {
+		name string
+		opts ResampleOptions
+		want []float64
+	}{
+		{"complete buckets only", ResampleOptions{Interval: 30 * time.Minute}, []float64{1335, 435, 1335}},
+		{"scaled partial sum", ResampleOptions{Interval: 30 * time.Minute, MinCoverage: 0.9}, []float64{420 * 30 / 29.0, 1335, 435, 1335}},
+		{"mean", ResampleOptions{Interval: time.Hour, Aggregate: AggregateMean, MinCoverage: 0.5}, []float64{1755 / 59.0, 29.5}},
+		{"max", ResampleOptions{Interval: time.Hour, Aggregate: AggregateMax}, []float64{59}},
+		{"min", ResampleOptions{Interval: time.Hour, Aggregate: AggregateMin}, []float64{0}},
+		{"median", ResampleOptions{Interval: time.Hour, Aggregate: AggregatePercentile(50)}, []float64{29.5}},
+		{"p90", ResampleOptions{Interval: time.Hour, Aggregate: AggregatePercentile(90)}, []float64{53.1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resampled, err := ResampleWith(data, tt.opts)
+			assert.NoError(t, err)
+			got := make([]float64, len(resampled))
+			for i, d := range resampled {
+				got[i] = d.Requests
+			}
+			assert.InDeltaSlice(t, tt.want, got, 1e-9)
+		})
+	}
+
+	// Buckets align to midnight in the requested location
+	resampled, err := ResampleWith(data, ResampleOptions{Interval: 90 * time.Minute, Location: shanghai, MinCoverage: 0.1})
+	assert.NoError(t, err)
+	if assert.Len(t, resampled, 2) {
+		assert.Equal(t, time.Date(2024, 1, 2, 6, 0, 0, 0, shanghai), resampled[0].Timestamp)
+		assert.Equal(t, time.Date(2024, 1, 2, 7, 30, 0, 0, shanghai), resampled[1].Timestamp)
+	}
+
+	_, err = ResampleWith(data, ResampleOptions{Interval: 90 * time.Second})
+	assert.Error(t, err)
+}
+
+func TestResampleDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	// Per-minute data over the 23-hour day clocks go forward and the following day
+	start := time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)
+	end := time.Date(2024, 3, 12, 0, 0, 0, 0, newYork)
+	var data []types.TrafficData
+	for ts := start; ts.Before(end); ts = ts.Add(time.Minute) {
+		data = append(data, types.TrafficData{Timestamp: ts, Requests: 1})
+	}
+
+	// Two-hour buckets stay on even local hours after the change
+	resampled, err := ResampleWith(data, ResampleOptions{Interval: 2 * time.Hour})
+	assert.NoError(t, err)
+	assert.Len(t, resampled, 23)
+	for _, d := range resampled {
+		assert.Zero(t, d.Timestamp.Hour()%2, d.Timestamp.String())
+		assert.Equal(t, 120.0, d.Requests)
+	}
+
+	// Daily buckets start at local midnight
+	resampled, err = ResampleWith(data, ResampleOptions{Interval: 24 * time.Hour, Aggregate: AggregateMean, MinCoverage: 0.9})
+	assert.NoError(t, err)
+	if assert.Len(t, resampled, 2) {
+		assert.Equal(t, start, resampled[0].Timestamp)
+		assert.Equal(t, start.AddDate(0, 0, 1), resampled[1].Timestamp)
+	}
+
+	// The repeated hour when clocks go back is a bucket of its own
+	start = time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)
+	data = data[:0]
+	for ts := start; ts.Before(start.AddDate(0, 0, 1)); ts = ts.Add(time.Minute) {
+		data = append(data, types.TrafficData{Timestamp: ts, Requests: 1})
+	}
+	resampled, err = ResampleWith(data, ResampleOptions{Interval: time.Hour})
+	assert.NoError(t, err)
+	if assert.Len(t, resampled, 25) {
+		assert.Equal(t, 1, resampled[1].Timestamp.Hour())
+		assert.Equal(t, 1, resampled[2].Timestamp.Hour())
+		assert.Equal(t, time.Hour, resampled[2].Timestamp.Sub(resampled[1].Timestamp))
+	}
+}
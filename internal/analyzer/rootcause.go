@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// minSurprise is the summed surprise below which a dimension is not reported, as its
+// values kept their shares of the total and it explains nothing about where the change is
+const minSurprise = 1e-9
+
+// LabeledSeries is the traffic of one combination of dimension values, e.g.
+// {"idc": "us-west", "endpoint": "/search"}
+type LabeledSeries struct {
+	Labels   map[string]string
+	Current  []types.TrafficData
+	Baseline []types.TrafficData
+}
+
+// RootCauseOptions configures LocalizeRootCause
+type RootCauseOptions struct {
+	MinExplanatoryPower    float64 // values explaining less of the change are ignored
+	TargetExplanatoryPower float64 // a dimension qualifies once its values explain this much of the change
+	MaxDimensions          int     // number of dimensions returned
+}
+
+// DefaultRootCauseOptions returns the thresholds of the Adtributor paper
+func DefaultRootCauseOptions() RootCauseOptions {
+	return RootCauseOptions{
+		MinExplanatoryPower:    0.1,
+		TargetExplanatoryPower: 0.67,
+		MaxDimensions:          3,
+	}
+}
+
+// LocalizeRootCause ranks the dimensions and values that explain the change of the
+// total traffic between the baselines and the current data, following Adtributor
+// (Bhagwan et al., NSDI 2014). Within each dimension the values are taken in order
+// of surprise until they explain TargetExplanatoryPower of the change; dimensions
+// that get there are ranked by the summed surprise of their values. Dimensions whose
+// values changed evenly are left out. It returns no causes when the total did not change.
+func LocalizeRootCause(series []LabeledSeries, opts RootCauseOptions) ([]types.RootCause, error) {
+	if len(series) == 0 {
+		return nil, fmt.Errorf("no series to localize")
+	}
+
+	// Aggregate expected and actual traffic per dimension value
+	expected := make(map[string]map[string]float64)
+	actual := make(map[string]map[string]float64)
+	var totalExpected, totalActual float64
+	for _, s := range series {
+		baselineMean := calculateMean(NewTimeSeriesAnalyzer(s.Baseline).values())
+		currentMean := calculateMean(NewTimeSeriesAnalyzer(s.Current).values())
+		totalExpected += baselineMean
+		totalActual += currentMean
+		for dimension, value := range s.Labels {
+			if expected[dimension] == nil {
+				expected[dimension] = make(map[string]float64)
+				actual[dimension] = make(map[string]float64)
+			}
+			expected[dimension][value] += baselineMean
+			actual[dimension][value] += currentMean
+		}
+	}
+	change := totalActual - totalExpected
+	if change == 0 || totalExpected == 0 || totalActual == 0 {
+		return nil, nil
+	}
+
+	var causes []types.RootCause
+	for dimension := range expected {
+		var values []types.RootCauseValue
+		for value, f := range expected[dimension] {
+			a := actual[dimension][value]
+			values = append(values, types.RootCauseValue{
+				Value:            value,
+				Expected:         f,
+				Actual:           a,
+				Surprise:         surprise(f/totalExpected, a/totalActual),
+				ExplanatoryPower: (a - f) / change,
+			})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Surprise != values[j].Surprise {
+				return values[i].Surprise > values[j].Surprise
+			}
+			return values[i].Value < values[j].Value
+		})
+
+		cause := types.RootCause{Dimension: dimension}
+		for _, v := range values {
+			if v.ExplanatoryPower < opts.MinExplanatoryPower {
+				continue
+			}
+			cause.Values = append(cause.Values, v)
+			cause.Surprise += v.Surprise
+			cause.ExplanatoryPower += v.ExplanatoryPower
+			if cause.ExplanatoryPower >= opts.TargetExplanatoryPower {
+				if cause.Surprise >= minSurprise {
+					causes = append(causes, cause)
+				}
+				break
+			}
+		}
+	}
+
+	sort.Slice(causes, func(i, j int) bool {
+		if causes[i].Surprise != causes[j].Surprise {
+			return causes[i].Surprise > causes[j].Surprise
+		}
+		return causes[i].Dimension < causes[j].Dimension
+	})
+	if opts.MaxDimensions > 0 && len(causes) > opts.MaxDimensions {
+		causes = causes[:opts.MaxDimensions]
+	}
+	return causes, nil
+}
+
+// surprise returns the Jensen-Shannon divergence contribution of a value whose
+// share of the total moved from p to q
+func surprise(p, q float64) float64 {
+	m := (p + q) / 2
+	s := 0.0
+	if p > 0 {
+		s += p * math.Log(p/m)
+	}
+	if q > 0 {
+		s += q * math.Log(q/m)
+	}
+	return s / 2
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestLocalizeRootCause(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	level := func(value float64) []types.TrafficData {
+		data := make([]types.TrafficData, 60)
+		for i := range data {
+			data[i] = types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: value}
+		}
+		return data
+	}
+
+	// The /search endpoint surges in us-west only; clients are affected evenly
+	var series []LabeledSeries
+	for _, idc := range []string{"us-west", "us-east", "eu-central"} {
+		for _, endpoint := range []string{"/search", "/login"} {
+			for _, client := range []string{"ios", "android"} {
+				current := 100.0
+				if idc == "us-west" && endpoint == "/search" {
+					current = 400
+				}
+				series = append(series, LabeledSeries{
+					Labels:   map[string]string{"idc": idc, "endpoint": endpoint, "client": client},
+					Current:  level(current),
+					Baseline: level(100),
+				})
+			}
+		}
+	}
+
+	causes, err := LocalizeRootCause(series, DefaultRootCauseOptions())
+	assert.NoError(t, err)
+	// An evenly affected dimension is not surprising and is left out
+	if assert.Len(t, causes, 2) {
+		for _, cause := range causes {
+			assert.Contains(t, []string{"idc", "endpoint"}, cause.Dimension)
+			if assert.Len(t, cause.Values, 1) {
+				assert.Contains(t, []string{"us-west", "/search"}, cause.Values[0].Value)
+				assert.InDelta(t, 1.0, cause.ExplanatoryPower, 1e-9)
+			}
+		}
+	}
+
+	// No change leaves nothing to explain
+	for i := range series {
+		series[i].Current = series[i].Baseline
+	}
+	causes, err = LocalizeRootCause(series, DefaultRootCauseOptions())
+	assert.NoError(t, err)
+	assert.Empty(t, causes)
+
+	_, err = LocalizeRootCause(nil, DefaultRootCauseOptions())
+	assert.Error(t, err)
+}
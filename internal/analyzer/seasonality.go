@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"math"
+	"math/cmplx"
+	"sort"
+	"time"
+)
+
+// minSeasonalStrength is the autocorrelation a period needs to be reported
+const minSeasonalStrength = 0.3
+
+// maxPeriodCandidates is the number of periodogram peaks checked against the autocorrelation
+const maxPeriodCandidates = 5
+
+// SeasonalPeriod describes a detected periodicity of the series
+type SeasonalPeriod struct {
+	Period   int           // length of one cycle in samples
+	Duration time.Duration // length of one cycle in time
+	Strength float64       // autocorrelation of the detrended series at Period
+}
+
+// DetectSeasonality finds dominant periods of up to maxPeriod samples, strongest first.
+// Candidate periods are taken from the peaks of the periodogram and confirmed
+// by the autocorrelation of the linearly detrended series, which leaves out imputed
+// points. A non-positive maxPeriod allows periods up to half the series, so at least
+// two cycles are seen.
+func (a *TimeSeriesAnalyzer) DetectSeasonality(maxPeriod int) ([]SeasonalPeriod, error) {
+	if grid, positions := a.onGrid(); positions != nil {
+		return grid.DetectSeasonality(maxPeriod)
+	}
+	values := detrend(a.values())
+	if maxPeriod <= 0 || maxPeriod > len(values)/2 {
+		maxPeriod = len(values) / 2
+	}
+	if maxPeriod < 2 {
+		return nil, nil
+	}
+
+	var interval time.Duration
+	if len(a.data) >= 2 {
+		interval = a.data[1].Timestamp.Sub(a.data[0].Timestamp)
+	}
+
+	var periods []SeasonalPeriod
+	seen := make(map[int]bool)
+	for _, candidate := range periodogramPeaks(values, maxPeriod) {
+		// The periodogram resolves long periods coarsely, so refine with the autocorrelation
+		lo := max(2, int(math.Floor(candidate*0.9))-1)
+		hi := min(maxPeriod, int(math.Ceil(candidate*1.1))+1)
+		best, bestACF := 0, math.Inf(-1)
+		for lag := lo; lag <= hi; lag++ {
+			if r := autocorrelation(values, a.imputed, lag); r > bestACF {
+				best, bestACF = lag, r
+			}
+		}
+		if best == 0 || seen[best] || bestACF < minSeasonalStrength {
+			continue
+		}
+		// Require a local autocorrelation peak rather than the edge of a decaying slope
+		if autocorrelation(values, a.imputed, best-1) > bestACF || autocorrelation(values, a.imputed, best+1) > bestACF {
+			continue
+		}
+		seen[best] = true
+		periods = append(periods, SeasonalPeriod{
+			Period:   best,
+			Duration: time.Duration(best) * interval,
+			Strength: bestACF,
+		})
+	}
+
+	sort.SliceStable(periods, func(i, j int) bool { return periods[i].Strength > periods[j].Strength })
+	return periods, nil
+}
+
+// dominantPeriod returns the strongest detected period, or zero if there is none
+func (a *TimeSeriesAnalyzer) dominantPeriod() int {
+	periods, err := a.DetectSeasonality(0)
+	if err != nil || len(periods) == 0 {
+		return 0
+	}
+	return periods[0].Period
+}
+
+// SelectSeasonalARIMA selects an ARIMA model using the dominant detected period as
+// the seasonal period, falling back to a non-seasonal search without seasonality
+func (a *TimeSeriesAnalyzer) SelectSeasonalARIMA() (*ARIMAModel, error) {
+	return a.SelectARIMA(seasonalSearch(a.dominantPeriod()))
+}
+
+// seasonalSearch extends the default search with first-order seasonal terms of the
+// given period, or returns the default search if period is zero
+func seasonalSearch(period int) ARIMASearch {
+	search := DefaultARIMASearch()
+	if period > 0 {
+		search.Period = period
+		search.MaxSP, search.MaxSQ = 1, 1
+	}
+	return search
+}
+
+// periodogramPeaks returns the periods of the largest local maxima of the periodogram
+func periodogramPeaks(values []float64, maxPeriod int) []float64 {
+	size := 1
+	for size < len(values) {
+		size <<= 1
+	}
+	buf := make([]complex128, size)
+	for i, v := range values {
+		buf[i] = complex(v, 0)
+	}
+	fft(buf)
+
+	power := make([]float64, size/2+1)
+	for k := range power {
+		power[k] = cmplx.Abs(buf[k]) * cmplx.Abs(buf[k])
+	}
+
+	type peak struct {
+		period float64
+		power  float64
+	}
+	var peaks []peak
+	for k := 1; k < len(power)-1; k++ {
+		period := float64(size) / float64(k)
+		if period < 2 || period > float64(maxPeriod) {
+			continue
+		}
+		if power[k] > power[k-1] && power[k] >= power[k+1] {
+			peaks = append(peaks, peak{period: period, power: power[k]})
+		}
+	}
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].power > peaks[j].power })
+
+	periods := make([]float64, 0, maxPeriodCandidates)
+	for i := 0; i < len(peaks) && i < maxPeriodCandidates; i++ {
+		periods = append(periods, peaks[i].period)
+	}
+	return periods
+}
+
+// fft computes the discrete Fourier transform in place; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	// Bit-reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	// Iterative Cooley-Tukey butterflies
+	for length := 2; length <= n; length <<= 1 {
+		w := cmplx.Exp(complex(0, -2*math.Pi/float64(length)))
+		for start := 0; start < n; start += length {
+			wk := complex(1, 0)
+			for k := 0; k < length/2; k++ {
+				u := x[start+k]
+				v := x[start+k+length/2] * wk
+				x[start+k] = u + v
+				x[start+k+length/2] = u - v
+				wk *= w
+			}
+		}
+	}
+}
+
+// autocorrelation returns the sample autocorrelation of values at lag, over the points
+// not marked in imputed, which may be nil. The lagged products are scaled up for the
+// pairs lost to imputed points.
+func autocorrelation(values []float64, imputed []bool, lag int) float64 {
+	if lag >= len(values) {
+		return 0
+	}
+	skip := func(i int) bool { return i < len(imputed) && imputed[i] }
+	mean, count := 0.0, 0
+	for i, v := range values {
+		if !skip(i) {
+			mean += v
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	mean /= float64(count)
+
+	num, den := 0.0, 0.0
+	pairs := 0
+	for i, v := range values {
+		if skip(i) {
+			continue
+		}
+		d := v - mean
+		den += d * d
+		if i+lag < len(values) && !skip(i+lag) {
+			num += d * (values[i+lag] - mean)
+			pairs++
+		}
+	}
+	if den == 0 || pairs == 0 {
+		return 0
+	}
+	return num / den * float64(count-lag) / float64(pairs)
+}
+
+// detrend removes the least-squares linear trend from values
+func detrend(values []float64) []float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return append([]float64(nil), values...)
+	}
+	meanX := (n - 1) / 2
+	meanY := calculateMean(values)
+	sxy, sxx := 0.0, 0.0
+	for i, v := range values {
+		dx := float64(i) - meanX
+		sxy += dx * (v - meanY)
+		sxx += dx * dx
+	}
+	slope := sxy / sxx
+
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = v - meanY - slope*(float64(i)-meanX)
+	}
+	return out
+}
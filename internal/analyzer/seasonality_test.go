@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// generateSeasonalData creates hourly data with a trend and a cycle of the given period
+func generateSeasonalData(n, period int, seed uint64) []types.TrafficData {
+	rng := rand.New(rand.NewPCG(seed, seed+1))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, n)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Hour),
+			Requests:  100 + 0.2*float64(i) + 30*math.Sin(2*math.Pi*float64(i)/float64(period)) + rng.NormFloat64()*3,
+		}
+	}
+	return data
+}
+
+func TestDetectSeasonality(t *testing.T) {
+	analyzer := NewTimeSeriesAnalyzer(generateSeasonalData(24*14, 24, 11))
+
+	periods, err := analyzer.DetectSeasonality(0)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, periods) {
+		assert.Equal(t, 24, periods[0].Period)
+		assert.Equal(t, 24*time.Hour, periods[0].Duration)
+		assert.Greater(t, periods[0].Strength, 0.8)
+	}
+
+	// Automatic period selection in CalculateSeasonality
+	indices, err := analyzer.CalculateSeasonality(0)
+	assert.NoError(t, err)
+	assert.Len(t, indices, 24)
+}
+
+func TestDetectSeasonalityNoise(t *testing.T) {
+	rng := rand.New(rand.NewPCG(12, 13))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 500)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  100 + rng.NormFloat64()*10,
+		}
+	}
+
+	periods, err := NewTimeSeriesAnalyzer(data).DetectSeasonality(0)
+	assert.NoError(t, err)
+	assert.Empty(t, periods)
+}
+
+func TestDecomposeDetectedPeriod(t *testing.T) {
+	analyzer := NewTimeSeriesAnalyzer(generateSeasonalData(24*14, 24, 14))
+
+	trend, seasonal, residual, err := analyzer.Decompose()
+	assert.NoError(t, err)
+
+	// The seasonal component repeats daily and carries the sine amplitude
+	assert.InDelta(t, seasonal[6], seasonal[30], 1e-9)
+	assert.InDelta(t, 30, seasonal[6], 3)
+	assert.InDelta(t, -30, seasonal[18], 3)
+
+	// Away from the edges the residual is just the noise
+	inner := residual[24 : len(residual)-24]
+	assert.Less(t, calculateStdDev(inner, calculateMean(inner)), 5.0)
+	assert.InDelta(t, 100+0.2*100, trend[100], 3)
+}
+
+func TestSelectSeasonalARIMA(t *testing.T) {
+	model, err := NewTimeSeriesAnalyzer(generateSeasonalData(24*10, 24, 15)).SelectSeasonalARIMA()
+	assert.NoError(t, err)
+	assert.Equal(t, 24, model.Order.Period)
+}
+
+func TestFFT(t *testing.T) {
+	// The transform of a unit impulse is flat
+	x := make([]complex128, 8)
+	x[0] = 1
+	fft(x)
+	for _, v := range x {
+		assert.InDelta(t, 1, real(v), 1e-12)
+		assert.InDelta(t, 0, imag(v), 1e-12)
+	}
+
+	// A pure cosine concentrates its energy in one bin
+	x = make([]complex128, 16)
+	for i := range x {
+		x[i] = complex(math.Cos(2*math.Pi*3*float64(i)/16), 0)
+	}
+	fft(x)
+	assert.InDelta(t, 8, real(x[3]), 1e-9)
+	assert.InDelta(t, 0, real(x[2]), 1e-9)
+}
@@ -0,0 +1,262 @@
+package analyzer
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// StreamingConfig configures a StreamingAnalyzer
+type StreamingConfig struct {
+	Window    int     // number of recent points kept for the rolling statistics
+	Alpha     float64 // smoothing factor of the EWMA and of the forecast level
+	Beta      float64 // smoothing factor of the forecast trend
+	Threshold float64 // anomaly threshold in standard deviations of the forecast error
+}
+
+// DefaultStreamingConfig returns a one-hour window for per-minute data and a threshold of 4
+func DefaultStreamingConfig() StreamingConfig {
+	return StreamingConfig{
+		Window:    60,
+		Alpha:     0.3,
+		Beta:      0.05,
+		Threshold: 4,
+	}
+}
+
+// AnomalyEvent is emitted when a streamed point deviates from its forecast
+type AnomalyEvent struct {
+	Point    types.TrafficData
+	Expected float64 // one-step forecast made before the point arrived
+	Score    float64 // forecast error in standard deviations
+	Severity types.Severity
+}
+
+// StreamingAnalyzer evaluates traffic point by point. Rolling mean and variance,
+// EWMA and the Holt forecast are updated in O(1) per point, the rolling median
+// in O(log n) of the window size.
+type StreamingAnalyzer struct {
+	config StreamingConfig
+
+	// Rolling window statistics
+	window []float64
+	next   int
+	mean   float64
+	m2     float64
+	median *slidingMedian
+
+	// Exponentially weighted statistics
+	count       int
+	ewma        float64
+	level       float64
+	trend       float64
+	errorVar    float64
+	initialized bool
+}
+
+// NewStreamingAnalyzer creates a streaming analyzer
+func NewStreamingAnalyzer(config StreamingConfig) *StreamingAnalyzer {
+	if config.Window < 2 {
+		config.Window = 2
+	}
+	return &StreamingAnalyzer{
+		config: config,
+		window: make([]float64, 0, config.Window),
+		median: newSlidingMedian(),
+	}
+}
+
+// Add ingests the next point and returns an anomaly event if the point deviates
+// from the forecast by more than the threshold. Points are only scored once a
+// full window has been seen.
+func (s *StreamingAnalyzer) Add(point types.TrafficData) (AnomalyEvent, bool) {
+	x := point.Requests
+	s.count++
+
+	if !s.initialized {
+		s.initialized = true
+		s.ewma, s.level = x, x
+		s.addToWindow(x)
+		return AnomalyEvent{}, false
+	}
+
+	// Score the point against the forecast made before it arrived
+	raw := x
+	expected := s.level + s.trend
+	err := x - expected
+	stdDev := math.Sqrt(s.errorVar)
+	event, anomalous := AnomalyEvent{}, false
+	if s.count > s.config.Window && stdDev > 0 {
+		score := err / stdDev
+		if math.Abs(score) > s.config.Threshold {
+			event = AnomalyEvent{Point: point, Expected: expected, Score: score, Severity: severityForScore(score)}
+			anomalous = true
+			// Clip the anomaly so it does not drag the forecast state along; the
+			// rolling statistics and EWMA still see the observed value
+			x = expected + math.Copysign(s.config.Threshold*stdDev, err)
+			err = x - expected
+		}
+	}
+
+	// Holt's linear trend update
+	alpha, beta := s.config.Alpha, s.config.Beta
+	previousLevel := s.level
+	s.level = alpha*x + (1-alpha)*(s.level+s.trend)
+	s.trend = beta*(s.level-previousLevel) + (1-beta)*s.trend
+	// The error variance is smoothed over the window span, much slower than the level
+	span := 2 / float64(s.config.Window+1)
+	s.errorVar = span*err*err + (1-span)*s.errorVar
+
+	s.ewma = alpha*raw + (1-alpha)*s.ewma
+	s.addToWindow(raw)
+
+	return event, anomalous
+}
+
+// addToWindow updates the rolling mean, variance and median with x, evicting the oldest point once full
+func (s *StreamingAnalyzer) addToWindow(x float64) {
+	if len(s.window) < s.config.Window {
+		s.window = append(s.window, x)
+		delta := x - s.mean
+		s.mean += delta / float64(len(s.window))
+		s.m2 += delta * (x - s.mean)
+		s.median.add(x)
+		return
+	}
+
+	old := s.window[s.next]
+	s.window[s.next] = x
+	s.next = (s.next + 1) % len(s.window)
+
+	oldMean := s.mean
+	s.mean += (x - old) / float64(len(s.window))
+	s.m2 += (x - old) * (x - s.mean + old - oldMean)
+	if s.m2 < 0 {
+		s.m2 = 0
+	}
+	s.median.remove(old)
+	s.median.add(x)
+}
+
+// Count returns the number of points ingested
+func (s *StreamingAnalyzer) Count() int { return s.count }
+
+// Mean returns the mean of the rolling window
+func (s *StreamingAnalyzer) Mean() float64 { return s.mean }
+
+// StdDev returns the sample standard deviation of the rolling window
+func (s *StreamingAnalyzer) StdDev() float64 {
+	if len(s.window) < 2 {
+		return 0
+	}
+	return math.Sqrt(s.m2 / float64(len(s.window)-1))
+}
+
+// Median returns the median of the rolling window
+func (s *StreamingAnalyzer) Median() float64 { return s.median.value() }
+
+// EWMA returns the exponentially weighted moving average
+func (s *StreamingAnalyzer) EWMA() float64 { return s.ewma }
+
+// Forecast extrapolates the current level and trend steps points ahead
+func (s *StreamingAnalyzer) Forecast(steps int) []float64 {
+	forecast := make([]float64, steps)
+	for i := range forecast {
+		forecast[i] = s.level + float64(i+1)*s.trend
+	}
+	return forecast
+}
+
+// slidingMedian tracks the median of a multiset with insertions and deletions in
+// O(log n), using two heaps and lazy deletion
+type slidingMedian struct {
+	low, high         *floatHeap // low is a max-heap of the smaller half (stored negated)
+	lowSize, highSize int        // number of live elements in each heap
+	delayed           map[float64]int
+}
+
+func newSlidingMedian() *slidingMedian {
+	return &slidingMedian{low: &floatHeap{}, high: &floatHeap{}, delayed: make(map[float64]int)}
+}
+
+func (m *slidingMedian) add(x float64) {
+	if m.lowSize == 0 || x <= -(*m.low)[0] {
+		heap.Push(m.low, -x)
+		m.lowSize++
+	} else {
+		heap.Push(m.high, x)
+		m.highSize++
+	}
+	m.rebalance()
+}
+
+func (m *slidingMedian) remove(x float64) {
+	m.delayed[x]++
+	if x <= -(*m.low)[0] {
+		m.lowSize--
+		if x == -(*m.low)[0] {
+			m.prune(m.low, -1)
+		}
+	} else {
+		m.highSize--
+		if x == (*m.high)[0] {
+			m.prune(m.high, 1)
+		}
+	}
+	m.rebalance()
+}
+
+func (m *slidingMedian) value() float64 {
+	if m.lowSize == 0 {
+		return 0
+	}
+	if m.lowSize > m.highSize {
+		return -(*m.low)[0]
+	}
+	return (-(*m.low)[0] + (*m.high)[0]) / 2
+}
+
+// rebalance keeps the low half equal to or one larger than the high half
+func (m *slidingMedian) rebalance() {
+	if m.lowSize > m.highSize+1 {
+		heap.Push(m.high, -heap.Pop(m.low).(float64))
+		m.lowSize--
+		m.highSize++
+		m.prune(m.low, -1)
+	} else if m.lowSize < m.highSize {
+		heap.Push(m.low, -heap.Pop(m.high).(float64))
+		m.highSize--
+		m.lowSize++
+		m.prune(m.high, 1)
+	}
+}
+
+// prune pops deleted elements off the top of h; sign converts stored values back
+func (m *slidingMedian) prune(h *floatHeap, sign float64) {
+	for h.Len() > 0 {
+		top := sign * (*h)[0]
+		if m.delayed[top] == 0 {
+			return
+		}
+		m.delayed[top]--
+		if m.delayed[top] == 0 {
+			delete(m.delayed, top)
+		}
+		heap.Pop(h)
+	}
+}
+
+// floatHeap is a min-heap of float64 values
+type floatHeap []float64
+
+func (h floatHeap) Len() int           { return len(h) }
+func (h floatHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h floatHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *floatHeap) Push(x any)        { *h = append(*h, x.(float64)) }
+func (h *floatHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+func TestStreamingRollingStatistics(t *testing.T) {
+	config := DefaultStreamingConfig()
+	config.Window = 25
+	s := NewStreamingAnalyzer(config)
+
+	rng := rand.New(rand.NewPCG(21, 22))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var values []float64
+	for i := 0; i < 500; i++ {
+		// Integer values produce plenty of duplicates for the median heaps
+		v := float64(rng.IntN(20))
+		values = append(values, v)
+		s.Add(types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: v})
+
+		window := values[max(0, len(values)-config.Window):]
+		assert.InDelta(t, calculateMean(window), s.Mean(), 1e-9)
+		assert.InDelta(t, calculateMedian(window), s.Median(), 1e-9)
+		assert.InDelta(t, calculateStdDev(window, calculateMean(window)), s.StdDev(), 1e-6)
+	}
+	assert.Equal(t, 500, s.Count())
+}
+
+func TestStreamingAnomalyEvents(t *testing.T) {
+	s := NewStreamingAnalyzer(DefaultStreamingConfig())
+	rng := rand.New(rand.NewPCG(23, 24))
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var events []AnomalyEvent
+	for i := 0; i < 600; i++ {
+		requests := 100 + 0.1*float64(i) + rng.NormFloat64()*2
+		if i == 400 {
+			requests += 80
+		}
+		if event, ok := s.Add(types.TrafficData{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Requests: requests}); ok {
+			events = append(events, event)
+		}
+	}
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, baseTime.Add(400*time.Minute), events[0].Point.Timestamp)
+		assert.Greater(t, events[0].Score, 4.0)
+		assert.InDelta(t, 140, events[0].Expected, 5)
+	}
+
+	// The spike is clipped, so the forecast keeps following the trend
+	forecast := s.Forecast(10)
+	assert.Len(t, forecast, 10)
+	assert.InDelta(t, 100+0.1*609, forecast[9], 5)
+	assert.InDelta(t, 160, s.EWMA(), 5)
+}
@@ -1,7 +1,11 @@
 package analyzer
 
 import (
+	"fmt"
 	"math"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/whichonezhang/traffic_monitor/internal/types"
@@ -9,7 +13,8 @@ import (
 
 // TimeSeriesAnalyzer handles advanced time series analysis
 type TimeSeriesAnalyzer struct {
-	data []types.TrafficData
+	data    []types.TrafficData
+	imputed []bool // points filled in by Impute, nil if none
 }
 
 // NewTimeSeriesAnalyzer creates a new time series analyzer
@@ -20,25 +25,46 @@ func NewTimeSeriesAnalyzer(data []types.TrafficData) *TimeSeriesAnalyzer {
 }
 
 // Decompose decomposes the time series into trend, seasonal, and residual components
+// using the dominant period found by DetectSeasonality. Without a detectable
+// period the seasonal component is zero and a 7-point moving average is the trend.
 func (a *TimeSeriesAnalyzer) Decompose() (trend, seasonal, residual []float64, err error) {
 	if len(a.data) < 2 {
 		return nil, nil, nil, nil
 	}
+	return a.DecomposePeriod(a.dominantPeriod())
+}
 
-	// Extract values and timestamps
-	values := make([]float64, len(a.data))
-	timestamps := make([]time.Time, len(a.data))
-	for i, d := range a.data {
-		values[i] = d.Requests
-		timestamps[i] = d.Timestamp
+// DecomposePeriod performs a classical additive decomposition with the given period
+func (a *TimeSeriesAnalyzer) DecomposePeriod(period int) (trend, seasonal, residual []float64, err error) {
+	if len(a.data) < 2 {
+		return nil, nil, nil, nil
+	}
+	if grid, positions := a.onGrid(); positions != nil {
+		trend, seasonal, residual, err = grid.DecomposePeriod(period)
+		return atPositions(trend, positions), atPositions(seasonal, positions), atPositions(residual, positions), err
 	}
 
-	// Calculate trend using moving average
-	windowSize := 7 // 7-point moving average
+	// Extract values
+	values := a.values()
+
+	// Calculate trend using a moving average spanning one full period
+	windowSize := 7 // 7-point moving average when there is no seasonality
+	if period >= 2 {
+		windowSize = period
+	}
 	trend = make([]float64, len(values))
 	for i := 0; i < len(values); i++ {
 		start := max(0, i-windowSize/2)
 		end := min(len(values), i+windowSize/2+1)
+		if windowSize%2 == 0 && end-start > windowSize {
+			// Even windows are centered by giving half weight to both ends
+			sum := 0.5 * (values[start] + values[end-1])
+			for j := start + 1; j < end-1; j++ {
+				sum += values[j]
+			}
+			trend[i] = sum / float64(windowSize)
+			continue
+		}
 		sum := 0.0
 		for j := start; j < end; j++ {
 			sum += values[j]
@@ -46,10 +72,28 @@ func (a *TimeSeriesAnalyzer) Decompose() (trend, seasonal, residual []float64, e
 		trend[i] = sum / float64(end-start)
 	}
 
-	// Calculate seasonal component
+	// Calculate seasonal component as the centered average of each phase of the detrended
+	// series, over the points that were not imputed
 	seasonal = make([]float64, len(values))
-	for i := 0; i < len(values); i++ {
-		seasonal[i] = values[i] - trend[i]
+	if period >= 2 {
+		indices := make([]float64, period)
+		counts := make([]int, period)
+		for i := range values {
+			if a.isImputed(i) {
+				continue
+			}
+			indices[i%period] += values[i] - trend[i]
+			counts[i%period]++
+		}
+		for i := range indices {
+			if counts[i] > 0 {
+				indices[i] /= float64(counts[i])
+			}
+		}
+		offset := calculateMean(indices)
+		for i := range seasonal {
+			seasonal[i] = indices[i%period] - offset
+		}
 	}
 
 	// Calculate residual component
@@ -61,67 +105,276 @@ func (a *TimeSeriesAnalyzer) Decompose() (trend, seasonal, residual []float64, e
 	return trend, seasonal, residual, nil
 }
 
-// DetectAnomalies detects anomalies using statistical methods
-func (a *TimeSeriesAnalyzer) DetectAnomalies() ([]int, error) {
+// DetectAnomalies detects points more than 3 standard deviations away from the
+// expected traffic and merges nearby points into time ranges
+func (a *TimeSeriesAnalyzer) DetectAnomalies() ([]types.Anomaly, error) {
+	grid, _ := a.onGrid()
+	return grid.detectSeasonalBand(3)
+}
+
+// detectSeasonalBand flags points outside a band of width standard deviations around the expected traffic
+func (a *TimeSeriesAnalyzer) detectSeasonalBand(width float64) ([]types.Anomaly, error) {
 	if len(a.data) < 2 {
 		return nil, nil
 	}
+	expected, residual, err := a.expectedTraffic()
+	if err != nil {
+		return nil, err
+	}
 
-	// Extract values
-	values := make([]float64, len(a.data))
-	for i, d := range a.data {
-		values[i] = d.Requests
+	// Calculate the residual standard deviation robustly so the anomalies themselves do not inflate it
+	stdDev := robustStdDev(a.observed(residual))
+
+	// Score each point (values outside width standard deviations are anomalous)
+	scores := make([]float64, len(residual))
+	for i, r := range residual {
+		if stdDev > 0 {
+			scores[i] = r / stdDev
+		}
 	}
 
-	// Calculate mean and standard deviation
-	mean := calculateMean(values)
-	stdDev := calculateStdDev(values, mean)
+	return a.anomalyRanges(expected, scores, width), nil
+}
 
-	// Detect anomalies (values outside 3 standard deviations)
-	var anomalies []int
-	for i, v := range values {
-		if math.Abs(v-mean) > 3*stdDev {
-			anomalies = append(anomalies, i)
+// expectedTraffic returns the expected traffic of every point and the residual of the
+// observed traffic from it. Expected traffic is the seasonal component plus a moving
+// median of the deseasonalized series, which unlike a moving average is not pulled
+// towards spikes.
+func (a *TimeSeriesAnalyzer) expectedTraffic() (expected, residual []float64, err error) {
+	period := a.dominantPeriod()
+	_, seasonal, _, err := a.DecomposePeriod(period)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompose series: %w", err)
+	}
+	values := a.values()
+	window := min(anomalyBaselineWindow, len(values)/4|1)
+	if period >= 7 {
+		window = period
+	}
+	deseasonalized := make([]float64, len(values))
+	for i := range values {
+		deseasonalized[i] = values[i] - seasonal[i]
+	}
+	baseline := movingMedian(deseasonalized, window)
+	expected = make([]float64, len(values))
+	residual = make([]float64, len(values))
+	for i := range values {
+		expected[i] = baseline[i] + seasonal[i]
+		residual[i] = values[i] - expected[i]
+	}
+	return expected, residual, nil
+}
+
+// anomalyBaselineWindow is the moving median window used when the series has no detectable
+// period; shorter series use a quarter of their length so a trend is still followed
+const anomalyBaselineWindow = 61
+
+// anomalyRangeGap is the number of normal points allowed inside a single anomaly range
+const anomalyRangeGap = 2
+
+// anomalyRanges merges points whose absolute score exceeds threshold into anomaly ranges
+func (a *TimeSeriesAnalyzer) anomalyRanges(expected, scores []float64, threshold float64) []types.Anomaly {
+	flagged := make([]bool, len(scores))
+	for i, score := range scores {
+		flagged[i] = math.Abs(score) > threshold
+	}
+	return a.flaggedRanges(flagged, nil, expected, scores)
+}
+
+// flaggedRanges merges flagged points into anomaly ranges. When rules is not nil,
+// rules[i] names the rule that flagged point i and each range lists its rules.
+// Imputed points are never flagged and ranges do not extend across gaps in the data.
+func (a *TimeSeriesAnalyzer) flaggedRanges(flagged []bool, rules []string, expected, scores []float64) []types.Anomaly {
+	interval, err := nativeInterval(a.data)
+	if err != nil {
+		return nil
+	}
+
+	var anomalies []types.Anomaly
+	var observedSum, expectedSum float64
+	var rangeRules []string
+	count, last := 0, -1
+	flush := func() {
+		if count == 0 {
+			return
 		}
+		current := &anomalies[len(anomalies)-1]
+		current.End = a.data[last].Timestamp.Add(interval)
+		current.Observed = observedSum / float64(count)
+		current.Expected = expectedSum / float64(count)
+		current.Severity = severityForScore(current.Score)
+		current.Rule = strings.Join(rangeRules, ",")
+		observedSum, expectedSum, count, rangeRules = 0, 0, 0, nil
 	}
 
-	return anomalies, nil
+	maxSpan := time.Duration(anomalyRangeGap+1) * interval
+	for i, score := range scores {
+		if !flagged[i] || a.isImputed(i) {
+			continue
+		}
+		if count == 0 || i-last > anomalyRangeGap+1 || a.data[i].Timestamp.Sub(a.data[last].Timestamp) > maxSpan {
+			flush()
+			anomalies = append(anomalies, types.Anomaly{Start: a.data[i].Timestamp})
+		}
+		current := &anomalies[len(anomalies)-1]
+		if math.Abs(score) > math.Abs(current.Score) {
+			current.Score = score
+		}
+		if rules != nil && rules[i] != "" && !slices.Contains(rangeRules, rules[i]) {
+			rangeRules = append(rangeRules, rules[i])
+		}
+		observedSum += a.data[i].Requests
+		expectedSum += expected[i]
+		count++
+		last = i
+	}
+	flush()
+
+	return anomalies
 }
 
-// Forecast predicts future values using ARIMA model
-func (a *TimeSeriesAnalyzer) Forecast(steps int) ([]float64, error) {
-	if len(a.data) < 2 {
-		return nil, nil
+// severityForScore grades an anomaly by its largest absolute score
+func severityForScore(score float64) types.Severity {
+	switch abs := math.Abs(score); {
+	case abs >= 8:
+		return types.SeverityCritical
+	case abs >= 5:
+		return types.SeverityWarning
+	default:
+		return types.SeverityInfo
 	}
+}
 
-	// Extract values
-	values := make([]float64, len(a.data))
-	for i, d := range a.data {
-		values[i] = d.Requests
+// Forecast predicts future values by damping the last difference by a fixed factor
+// of 0.7 at each step, without fitting a model. It is the baseline CompareForecasts
+// measures ARIMA against; ForecastAt, SelectARIMA and FitARIMA use estimated models.
+// Trailing imputed points are forecast over rather than extrapolated from.
+func (a *TimeSeriesAnalyzer) Forecast(steps int) ([]float64, error) {
+	if grid, positions := a.onGrid(); positions != nil {
+		return grid.Forecast(steps)
+	}
+	last := len(a.data) - 1
+	for last >= 0 && a.isImputed(last) {
+		last--
 	}
+	if last < 1 {
+		return nil, nil
+	}
+	trailing := len(a.data) - 1 - last
+	values := a.values()[:last+1]
 
-	// Simple ARIMA(1,1,1) implementation
-	// This is a simplified version - in production, you might want to use a proper ARIMA library
-	forecast := make([]float64, steps)
+	// Damped extrapolation of the last difference
+	forecast := make([]float64, trailing+steps)
 	lastValue := values[len(values)-1]
 	lastDiff := values[len(values)-1] - values[len(values)-2]
 	phi := 0.7 // AR coefficient
 
-	for i := 0; i < steps; i++ {
-		// ARIMA(1,1,1) formula
+	for i := range forecast {
 		forecast[i] = lastValue + phi*lastDiff
 		lastDiff = forecast[i] - lastValue
 		lastValue = forecast[i]
 	}
 
+	return forecast[trailing:], nil
+}
+
+// ForecastAt predicts the next steps intervals of the given resolution. Data is
+// summed into resolution-sized buckets first, so ForecastAt(time.Hour, 24) on
+// per-minute data forecasts hourly request totals for the next 24 hours. Buckets of
+// imputed points only are forecast over like imputed points. Only the buckets after
+// the last gap, e.g. an hour dropped for missing data, are forecast from, with the
+// ARIMA model of lowest AIC, seasonal if they show a period.
+func (a *TimeSeriesAnalyzer) ForecastAt(resolution time.Duration, steps int) ([]types.ForecastPoint, error) {
+	if len(a.data) < 2 {
+		return nil, nil
+	}
+	buckets, err := a.ResampleWith(ResampleOptions{Interval: resolution})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resample data: %w", err)
+	}
+	buckets = buckets.afterLastGap(resolution)
+	resampled := buckets.data
+	if len(resampled) < 2 {
+		return nil, nil
+	}
+
+	values, err := buckets.arimaForecast(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	last := resampled[len(resampled)-1].Timestamp
+	forecast := make([]types.ForecastPoint, len(values))
+	for i, v := range values {
+		forecast[i] = types.ForecastPoint{
+			Timestamp: last.Add(time.Duration(i+1) * resolution),
+			Value:     v,
+		}
+	}
 	return forecast, nil
 }
 
-// CalculateSeasonality calculates the seasonal pattern in the data
+// minARIMAPoints is the number of observed points below which arimaForecast falls back
+// to Forecast, as AIC cannot tell model orders apart on fewer
+const minARIMAPoints = 12
+
+// arimaForecast predicts with the ARIMA model selected by AIC, seasonal with the
+// dominant period if there is one. As in Forecast, trailing imputed points are forecast
+// over. Series too short to fit a model fall back to Forecast.
+func (a *TimeSeriesAnalyzer) arimaForecast(steps int) ([]float64, error) {
+	last := len(a.data)
+	for last > 0 && a.isImputed(last-1) {
+		last--
+	}
+	if last < minARIMAPoints {
+		return a.Forecast(steps)
+	}
+	observed := &TimeSeriesAnalyzer{data: a.data[:last]}
+	if a.imputed != nil {
+		observed.imputed = a.imputed[:min(last, len(a.imputed))]
+	}
+
+	model, err := observed.SelectSeasonalARIMA()
+	if err != nil {
+		return a.Forecast(steps)
+	}
+	trailing := len(a.data) - last
+	forecast, err := model.Forecast(trailing + steps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run ARIMA forecast: %w", err)
+	}
+	return forecast[trailing:], nil
+}
+
+// afterLastGap returns the points following the last gap of more than one interval,
+// allowing for days of 23 or 25 hours across daylight saving changes
+func (a *TimeSeriesAnalyzer) afterLastGap(interval time.Duration) *TimeSeriesAnalyzer {
+	start := len(a.data) - 1
+	for start > 0 && a.data[start].Timestamp.Sub(a.data[start-1].Timestamp) <= interval+interval/2 {
+		start--
+	}
+	if start <= 0 {
+		return a
+	}
+	run := &TimeSeriesAnalyzer{data: a.data[start:]}
+	if a.imputed != nil {
+		run.imputed = a.imputed[start:]
+	}
+	return run
+}
+
+// CalculateSeasonality calculates the seasonal pattern in the data, leaving out imputed
+// points. A non-positive period uses the dominant period found by DetectSeasonality.
 func (a *TimeSeriesAnalyzer) CalculateSeasonality(period int) ([]float64, error) {
-	if len(a.data) < period {
+	if period <= 0 {
+		period = a.dominantPeriod()
+	}
+	if period <= 0 || len(a.data) < period {
 		return nil, nil
 	}
+	if grid, positions := a.onGrid(); positions != nil {
+		return grid.CalculateSeasonality(period)
+	}
 
 	// Extract values
 	values := make([]float64, len(a.data))
@@ -134,6 +387,9 @@ func (a *TimeSeriesAnalyzer) CalculateSeasonality(period int) ([]float64, error)
 	counts := make([]int, period)
 
 	for i, v := range values {
+		if a.isImputed(i) {
+			continue
+		}
 		idx := i % period
 		seasonalIndices[idx] += v
 		counts[idx]++
@@ -173,6 +429,80 @@ func calculateStdDev(values []float64, mean float64) float64 {
 	return math.Sqrt(sumSquares / float64(len(values)-1))
 }
 
+// robustStdDev estimates the standard deviation from the median absolute deviation
+func robustStdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	median := calculateMedian(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	mad := calculateMedian(deviations) * 1.4826
+	if mad == 0 {
+		return calculateStdDev(values, calculateMean(values))
+	}
+	return mad
+}
+
+// movingMedian returns the centered moving median. Within half a window of either end,
+// where a shrunken window is off-center and lags a trend, a robust line fitted to the
+// outermost full window is used instead if the series is long enough.
+func movingMedian(values []float64, window int) []float64 {
+	half := window / 2
+	medians := make([]float64, len(values))
+	for i := range values {
+		start := max(0, i-half)
+		end := min(len(values), i+half+1)
+		medians[i] = calculateMedian(values[start:end])
+	}
+
+	if half == 0 || len(values) < 2*window {
+		return medians
+	}
+	slope, intercept := theilSen(values[:window])
+	for i := 0; i < half; i++ {
+		medians[i] = intercept + slope*float64(i)
+	}
+	offset := len(values) - window
+	slope, intercept = theilSen(values[offset:])
+	for i := len(values) - half; i < len(values); i++ {
+		medians[i] = intercept + slope*float64(i-offset)
+	}
+	return medians
+}
+
+// theilSen fits a line to values by their index with the median of the pairwise slopes,
+// which unlike least squares is not pulled towards spikes
+func theilSen(values []float64) (slope, intercept float64) {
+	slopes := make([]float64, 0, len(values)*(len(values)-1)/2)
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			slopes = append(slopes, (values[j]-values[i])/float64(j-i))
+		}
+	}
+	slope = calculateMedian(slopes)
+	intercepts := make([]float64, len(values))
+	for i, v := range values {
+		intercepts[i] = v - slope*float64(i)
+	}
+	return slope, calculateMedian(intercepts)
+}
+
+func calculateMedian(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -30,7 +30,16 @@ func TestTimeSeriesAnalyzer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, anomalies)
 	// Should detect the spike at index 20
-	assert.Contains(t, anomalies, 20)
+	spike := data[20].Timestamp
+	found := false
+	for _, anomaly := range anomalies {
+		if !spike.Before(anomaly.Start) && spike.Before(anomaly.End) {
+			found = true
+			assert.Greater(t, anomaly.Score, 3.0)
+			assert.Greater(t, anomaly.Observed, anomaly.Expected)
+		}
+	}
+	assert.True(t, found, "spike at index 20 should be inside an anomaly range")
 
 	// Test forecasting
 	forecast, err := analyzer.Forecast(5)
@@ -75,3 +84,129 @@ func generateTestData() []types.TrafficData {
 
 	return data
 }
+
+func TestForecastAt(t *testing.T) {
+	// One day of per-minute data with a constant rate of 10 requests per minute
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 1440)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  10,
+		}
+	}
+	analyzer := NewTimeSeriesAnalyzer(data)
+
+	forecast, err := analyzer.ForecastAt(time.Hour, 24)
+	assert.NoError(t, err)
+	assert.Len(t, forecast, 24)
+	assert.Equal(t, baseTime.Add(24*time.Hour), forecast[0].Timestamp)
+	assert.Equal(t, baseTime.Add(47*time.Hour), forecast[23].Timestamp)
+	assert.InDelta(t, 600, forecast[0].Value, 1e-9)
+
+	forecast, err = analyzer.ForecastAt(time.Minute, 1440)
+	assert.NoError(t, err)
+	assert.Len(t, forecast, 1440)
+	assert.Equal(t, baseTime.Add(24*time.Hour), forecast[0].Timestamp)
+
+	// A partial trailing hour is not forecast from
+	forecast, err = NewTimeSeriesAnalyzer(data[:90]).ForecastAt(time.Hour, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, forecast)
+
+	_, err = analyzer.ForecastAt(90*time.Second, 1)
+	assert.Error(t, err)
+
+	forecast, err = NewTimeSeriesAnalyzer(data[:1]).ForecastAt(time.Hour, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, forecast)
+
+	// A missing hour is not taken as adjacent to the ones around it: traffic doubled
+	// during the gap and is forecast from the hours after it only
+	var gapped []types.TrafficData
+	for i, d := range data {
+		if hour := i / 60; hour > 21 {
+			d.Requests = 20
+			gapped = append(gapped, d)
+		} else if hour < 21 {
+			gapped = append(gapped, d)
+		}
+	}
+	forecast, err = NewTimeSeriesAnalyzer(gapped).ForecastAt(time.Hour, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, forecast, 1) {
+		assert.Equal(t, baseTime.Add(24*time.Hour), forecast[0].Timestamp)
+		assert.InDelta(t, 1200, forecast[0].Value, 1e-6)
+	}
+	forecast, err = NewTimeSeriesAnalyzer(gapped[:22*60]).ForecastAt(time.Hour, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, forecast)
+}
+
+func TestForecastAtSeasonal(t *testing.T) {
+	// Ten days of hourly traffic with a daily cycle; the eleventh is held out
+	data := generateSeasonalData(24*11, 24, 15)
+	a := NewTimeSeriesAnalyzer(data[:24*10])
+
+	forecast, err := a.ForecastAt(time.Hour, 24)
+	assert.NoError(t, err)
+	simple, err := a.Forecast(24)
+	assert.NoError(t, err)
+	if assert.Len(t, forecast, 24) {
+		// The seasonal model follows the cycle the damped extrapolation misses
+		var seasonalErr, simpleErr float64
+		for i, point := range forecast {
+			actual := data[24*10+i]
+			assert.Equal(t, actual.Timestamp, point.Timestamp)
+			seasonalErr = math.Max(seasonalErr, math.Abs(point.Value-actual.Requests))
+			simpleErr = math.Max(simpleErr, math.Abs(simple[i]-actual.Requests))
+		}
+		assert.Less(t, seasonalErr, 15.0)
+		assert.Greater(t, simpleErr, 30.0)
+	}
+
+	// Without a period the forecast comes from the non-seasonal model of lowest AIC
+	rng := rand.New(rand.NewPCG(3, 4))
+	noisy := make([]types.TrafficData, 48)
+	for i := range noisy {
+		noisy[i] = types.TrafficData{Timestamp: data[0].Timestamp.Add(time.Duration(i) * time.Hour), Requests: 100 + rng.NormFloat64()*5}
+	}
+	a = NewTimeSeriesAnalyzer(noisy)
+	model, err := a.SelectARIMA(DefaultARIMASearch())
+	assert.NoError(t, err)
+	expected, err := model.Forecast(3)
+	assert.NoError(t, err)
+	forecast, err = a.ForecastAt(time.Hour, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, forecast, 3) {
+		for i, point := range forecast {
+			assert.InDelta(t, expected[i], point.Value, 1e-9)
+		}
+	}
+}
+
+func TestDetectAnomalyRanges(t *testing.T) {
+	// Flat per-minute traffic with a 15-minute surge to 4.4x between 14:32 and 14:47
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := make([]types.TrafficData, 1440)
+	for i := range data {
+		requests := 100.0 + float64(i%5)
+		if i >= 14*60+32 && i < 14*60+47 {
+			requests *= 4.4
+		}
+		data[i] = types.TrafficData{
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Requests:  requests,
+		}
+	}
+
+	anomalies, err := NewTimeSeriesAnalyzer(data).DetectAnomalies()
+	assert.NoError(t, err)
+	if assert.Len(t, anomalies, 1) {
+		anomaly := anomalies[0]
+		assert.Equal(t, baseTime.Add((14*60+32)*time.Minute), anomaly.Start)
+		assert.Equal(t, baseTime.Add((14*60+47)*time.Minute), anomaly.End)
+		assert.InDelta(t, 3.4, anomaly.Deviation(), 0.1)
+		assert.Equal(t, types.SeverityCritical, anomaly.Severity)
+	}
+}
@@ -0,0 +1,266 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/data"
+	"github.com/whichonezhang/traffic_monitor/internal/monitor"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// labelTimeLayout is the timestamp format of label files
+const labelTimeLayout = "2006-01-02 15:04:05"
+
+// Label marks a known anomaly of a module and IDC
+type Label struct {
+	Module string
+	IDC    string
+	Start  time.Time
+	End    time.Time
+}
+
+// Alert is a time range flagged by the system under test
+type Alert struct {
+	Start    time.Time
+	End      time.Time
+	Detector string
+}
+
+// ErrNoData is returned by alert sources for days without current data to replay
+var ErrNoData = errors.New("no data to replay")
+
+// DayError is the error of a day that could not be replayed
+type DayError struct {
+	Date time.Time
+	Err  error
+}
+
+// Result summarizes how well alerts match the labels
+type Result struct {
+	Days          int           // days replayed successfully
+	SkippedDays   int           // days without current data
+	FailedDays    int           // days whose replay failed for another reason
+	Errors        []DayError    // errors of skipped and failed days, by date
+	Labels        int           // labeled anomalies on replayed days
+	SkippedLabels int           // labeled anomalies only on skipped or failed days, left out of recall
+	Detected      int           // labels overlapped by at least one alert
+	Alerts        int           // distinct alerts raised
+	TrueAlerts    int           // alerts overlapping at least one label
+	Precision     float64       // TrueAlerts / Alerts
+	Recall        float64       // Detected / Labels
+	F1            float64       // harmonic mean of precision and recall
+	MeanDelay     time.Duration // mean time from label start to the first overlapping alert
+	AlertsPerDay  float64
+}
+
+// AlertSource returns the alerts raised for a single day, or an error wrapping ErrNoData
+// when the day has no current data
+type AlertSource func(date time.Time) ([]Alert, error)
+
+// LoadLabels reads labels from a CSV file with rows of module,idc,start,end
+// where start and end use the layout "2006-01-02 15:04:05" in local time.
+// A header row starting with "module" is skipped.
+func LoadLabels(filename string) ([]Label, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open label file: %w", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels: %w", err)
+	}
+
+	var labels []Label
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "module") {
+			continue
+		}
+		if len(record) != 4 {
+			return nil, fmt.Errorf("invalid label at line %d: expected 4 columns, got %d", i+1, len(record))
+		}
+		start, err := time.ParseInLocation(labelTimeLayout, record[2], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label start at line %d: %w", i+1, err)
+		}
+		end, err := time.ParseInLocation(labelTimeLayout, record[3], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label end at line %d: %w", i+1, err)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("invalid label at line %d: end before start", i+1)
+		}
+		labels = append(labels, Label{Module: record[0], IDC: record[1], Start: start, End: end})
+	}
+	return labels, nil
+}
+
+// MonitorSource replays a day through Monitor.MonitorTraffic and turns the anomalies of
+// its notifications into alerts. Baselines without data are left out by the monitor.
+func MonitorSource(m *monitor.Monitor, module, idc string) AlertSource {
+	return func(date time.Time) ([]Alert, error) {
+		notifications, err := m.MonitorTraffic(module, idc, date)
+		if errors.Is(err, monitor.ErrNoCurrentData) {
+			return nil, fmt.Errorf("%w: %w", ErrNoData, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var alerts []Alert
+		for _, n := range notifications {
+			alerts = append(alerts, anomalyAlerts(n.Anomalies)...)
+			// Ensemble notifications keep the baseline detections per baseline
+			for _, b := range n.Baselines {
+				alerts = append(alerts, anomalyAlerts(b.Anomalies)...)
+			}
+		}
+		return alerts, nil
+	}
+}
+
+// DetectorSource replays a day through a single-series detector
+func DetectorSource(detector analyzer.Detector, provider data.Provider, module, idc string) AlertSource {
+	return func(date time.Time) ([]Alert, error) {
+		if detector.NeedsBaseline() {
+			return nil, fmt.Errorf("detector %s needs a baseline, replay it through a monitor instead", detector.Name())
+		}
+		current, err := provider.GetData(module, idc, date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrNoData, err)
+		}
+		anomalies, err := detector.Detect(analyzer.DetectionInput{Current: current})
+		if err != nil {
+			return nil, err
+		}
+		return anomalyAlerts(anomalies), nil
+	}
+}
+
+func anomalyAlerts(anomalies []types.Anomaly) []Alert {
+	alerts := make([]Alert, len(anomalies))
+	for i, a := range anomalies {
+		alerts[i] = Alert{Start: a.Start, End: a.End, Detector: a.Detector}
+	}
+	return alerts
+}
+
+// Run replays every day from from to to (inclusive) through source and scores the
+// alerts against the labels of module and IDC. Days without current data are skipped
+// and days whose replay fails otherwise are counted as failed; the errors of both are
+// kept in the result. Labels only on days that could not be replayed are counted
+// separately and left out of recall.
+func Run(source AlertSource, labels []Label, module, idc string, from, to time.Time) Result {
+	var result Result
+	var alerts []Alert
+	var replayed []time.Time
+	seen := make(map[Alert]bool)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		dayAlerts, err := source(date)
+		if err != nil {
+			if errors.Is(err, ErrNoData) {
+				result.SkippedDays++
+			} else {
+				result.FailedDays++
+			}
+			result.Errors = append(result.Errors, DayError{Date: date, Err: err})
+			continue
+		}
+		result.Days++
+		replayed = append(replayed, date)
+		// Notifications for several baselines repeat the same anomalies
+		for _, alert := range dayAlerts {
+			if !seen[alert] {
+				seen[alert] = true
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+
+	var relevant []Label
+	for _, label := range labels {
+		if label.Module != module || label.IDC != idc || !intersects(label, from, to.AddDate(0, 0, 1)) {
+			continue
+		}
+		if onReplayedDay(label, replayed) {
+			relevant = append(relevant, label)
+		} else {
+			result.SkippedLabels++
+		}
+	}
+
+	evaluate(&result, alerts, relevant)
+	return result
+}
+
+// onReplayedDay reports whether a label falls at least partly on one of the replayed days
+func onReplayedDay(label Label, replayed []time.Time) bool {
+	for _, date := range replayed {
+		if intersects(label, date, date.AddDate(0, 0, 1)) {
+			return true
+		}
+	}
+	return false
+}
+
+// intersects reports whether a label intersects the range from start up to end
+func intersects(label Label, start, end time.Time) bool {
+	return label.Start.Before(end) && !label.End.Before(start)
+}
+
+// evaluate fills the accuracy metrics of result
+func evaluate(result *Result, alerts []Alert, labels []Label) {
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Start.Before(alerts[j].Start) })
+
+	result.Labels = len(labels)
+	result.Alerts = len(alerts)
+
+	var totalDelay time.Duration
+	for _, label := range labels {
+		for _, alert := range alerts {
+			if overlaps(alert, label) {
+				result.Detected++
+				if delay := alert.Start.Sub(label.Start); delay > 0 {
+					totalDelay += delay
+				}
+				break
+			}
+		}
+	}
+	for _, alert := range alerts {
+		for _, label := range labels {
+			if overlaps(alert, label) {
+				result.TrueAlerts++
+				break
+			}
+		}
+	}
+
+	if result.Alerts > 0 {
+		result.Precision = float64(result.TrueAlerts) / float64(result.Alerts)
+	}
+	if result.Labels > 0 {
+		result.Recall = float64(result.Detected) / float64(result.Labels)
+	}
+	if result.Precision+result.Recall > 0 {
+		result.F1 = 2 * result.Precision * result.Recall / (result.Precision + result.Recall)
+	}
+	if result.Detected > 0 {
+		result.MeanDelay = totalDelay / time.Duration(result.Detected)
+	}
+	if result.Days > 0 {
+		result.AlertsPerDay = float64(result.Alerts) / float64(result.Days)
+	}
+}
+
+// overlaps reports whether an alert intersects a label; label ends are inclusive
+func overlaps(alert Alert, label Label) bool {
+	return alert.Start.Before(label.End.Add(time.Nanosecond)) && label.Start.Before(alert.End)
+}
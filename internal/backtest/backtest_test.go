@@ -0,0 +1,81 @@
+package backtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadLabels(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "labels.csv")
+	content := "module,idc,start,end\n" +
+		"api,us-west,2024-03-01 14:30:00,2024-03-01 14:45:00\n"
+	assert.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+
+	labels, err := LoadLabels(filename)
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.Equal(t, "api", labels[0].Module)
+		assert.Equal(t, time.Date(2024, 3, 1, 14, 30, 0, 0, time.Local), labels[0].Start)
+	}
+
+	assert.NoError(t, os.WriteFile(filename, []byte("api,us-west,2024-03-01 14:30:00,2024-03-01 14:00:00\n"), 0644))
+	_, err = LoadLabels(filename)
+	assert.Error(t, err, "end before start should be rejected")
+
+	_, err = LoadLabels(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.Local) }
+	at := func(d, h, m int) time.Time { return time.Date(2024, 3, d, h, m, 0, 0, time.Local) }
+
+	labels := []Label{
+		{Module: "api", IDC: "us-west", Start: at(1, 14, 30), End: at(1, 14, 45)},  // detected 5 minutes late
+		{Module: "api", IDC: "us-west", Start: at(2, 9, 0), End: at(2, 9, 30)},     // missed
+		{Module: "api", IDC: "eu-central", Start: at(1, 10, 0), End: at(1, 11, 0)}, // other series
+		{Module: "api", IDC: "us-west", Start: at(3, 8, 0), End: at(3, 8, 30)},     // on a skipped day
+		{Module: "api", IDC: "us-west", Start: at(4, 8, 0), End: at(4, 8, 30)},     // on a failed day
+	}
+
+	source := func(date time.Time) ([]Alert, error) {
+		switch date.Day() {
+		case 1:
+			// The same alert repeated, as for notifications of several baselines
+			alert := Alert{Start: at(1, 14, 35), End: at(1, 14, 50), Detector: "zscore"}
+			return []Alert{alert, alert, {Start: at(1, 20, 0), End: at(1, 20, 5)}}, nil
+		case 3:
+			return nil, fmt.Errorf("%w: missing file", ErrNoData)
+		case 4:
+			return nil, fmt.Errorf("invalid data")
+		default:
+			return nil, nil
+		}
+	}
+
+	result := Run(source, labels, "api", "us-west", day(1), day(4))
+	assert.Equal(t, 2, result.Days)
+	assert.Equal(t, 1, result.SkippedDays)
+	assert.Equal(t, 1, result.FailedDays)
+	if assert.Len(t, result.Errors, 2) {
+		assert.Equal(t, day(3), result.Errors[0].Date)
+		assert.ErrorIs(t, result.Errors[0].Err, ErrNoData)
+		assert.Equal(t, day(4), result.Errors[1].Date)
+		assert.EqualError(t, result.Errors[1].Err, "invalid data")
+	}
+	assert.Equal(t, 2, result.Labels)
+	assert.Equal(t, 2, result.SkippedLabels)
+	assert.Equal(t, 1, result.Detected)
+	assert.Equal(t, 2, result.Alerts)
+	assert.Equal(t, 1, result.TrueAlerts)
+	assert.InDelta(t, 0.5, result.Precision, 1e-9)
+	assert.InDelta(t, 0.5, result.Recall, 1e-9)
+	assert.InDelta(t, 0.5, result.F1, 1e-9)
+	assert.Equal(t, 5*time.Minute, result.MeanDelay)
+	assert.InDelta(t, 1.0, result.AlertsPerDay, 1e-9)
+}
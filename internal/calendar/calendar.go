@@ -0,0 +1,146 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Calendar resolves the festivals and day types of a region
+type Calendar interface {
+	// GetFestival returns the festival on the day of date
+	GetFestival(date time.Time) (string, bool)
+	// GetFestivalDay returns the festival whose window contains date, the first of GetFestivalDays
+	GetFestivalDay(date time.Time) (FestivalDay, bool)
+	// GetFestivalDays returns all festivals whose window contains date, highest priority first
+	GetFestivalDays(date time.Time) []FestivalDay
+	// GetFestivalOccurrences returns the n most recent occurrences of a festival before currentDate
+	GetFestivalOccurrences(currentDate time.Time, festival string, n int) ([]time.Time, error)
+	// GetFestivalDayOccurrences returns the dates at the same offset from the n previous occurrences of a festival
+	GetFestivalDayOccurrences(currentDate time.Time, day FestivalDay, n int) ([]time.Time, error)
+	// GetNextFestival returns the next upcoming festival and its date
+	GetNextFestival(currentDate time.Time) (string, time.Time, error)
+	// Festivals returns the names of the festivals the calendar knows
+	Festivals() []string
+	// DayType classifies date for baseline selection
+	DayType(date time.Time) DayType
+}
+
+// CombinedCalendar merges several calendars, e.g. Gregorian holidays and Chinese
+// festivals for an IDC serving both. Overlapping festivals are ordered by priority, and
+// by the order of their calendars on equal priority.
+type CombinedCalendar struct {
+	calendars []Calendar
+}
+
+// NewCombinedCalendar creates a calendar of the festivals of all the given calendars
+func NewCombinedCalendar(calendars ...Calendar) *CombinedCalendar {
+	return &CombinedCalendar{calendars: calendars}
+}
+
+// GetFestival returns the festival on the day of date, the highest priority one if there are several
+func (c *CombinedCalendar) GetFestival(date time.Time) (string, bool) {
+	return festivalOn(c.GetFestivalDays(date))
+}
+
+// GetFestivalDay returns the festival whose window contains date, the first of GetFestivalDays
+func (c *CombinedCalendar) GetFestivalDay(date time.Time) (FestivalDay, bool) {
+	return firstFestivalDay(c.GetFestivalDays(date))
+}
+
+// GetFestivalDays returns the festival days of date of all calendars, by descending
+// priority, then in calendar order
+func (c *CombinedCalendar) GetFestivalDays(date time.Time) []FestivalDay {
+	var days []FestivalDay
+	for _, cal := range c.calendars {
+		days = append(days, cal.GetFestivalDays(date)...)
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Priority > days[j].Priority })
+	return uniqueFestivalDays(days)
+}
+
+// GetFestivalOccurrences returns the occurrences of a festival from the first calendar that knows it
+func (c *CombinedCalendar) GetFestivalOccurrences(currentDate time.Time, festival string, n int) ([]time.Time, error) {
+	cal, err := c.calendarOf(festival)
+	if err != nil {
+		return nil, err
+	}
+	return cal.GetFestivalOccurrences(currentDate, festival, n)
+}
+
+// GetFestivalDayOccurrences returns the festival day occurrences from the first calendar that knows the festival
+func (c *CombinedCalendar) GetFestivalDayOccurrences(currentDate time.Time, day FestivalDay, n int) ([]time.Time, error) {
+	cal, err := c.calendarOf(day.Festival)
+	if err != nil {
+		return nil, err
+	}
+	return cal.GetFestivalDayOccurrences(currentDate, day, n)
+}
+
+// GetNextFestival returns the earliest upcoming festival of all calendars
+func (c *CombinedCalendar) GetNextFestival(currentDate time.Time) (string, time.Time, error) {
+	var nextFestival string
+	var nextDate time.Time
+	for _, cal := range c.calendars {
+		festival, date, err := cal.GetNextFestival(currentDate)
+		if err != nil {
+			continue
+		}
+		if nextFestival == "" || date.Before(nextDate) {
+			nextFestival = festival
+			nextDate = date
+		}
+	}
+	if nextFestival == "" {
+		return "", time.Time{}, fmt.Errorf("no upcoming festivals found")
+	}
+
+	// Of festivals on the same day, the highest priority one is next
+	nextFestival, _ = c.GetFestival(nextDate)
+	return nextFestival, nextDate, nil
+}
+
+// Festivals returns the festivals of all calendars, in calendar order
+func (c *CombinedCalendar) Festivals() []string {
+	var festivals []string
+	seen := make(map[string]bool)
+	for _, cal := range c.calendars {
+		for _, festival := range cal.Festivals() {
+			if !seen[festival] {
+				seen[festival] = true
+				festivals = append(festivals, festival)
+			}
+		}
+	}
+	return festivals
+}
+
+// DayType classifies date as a holiday if any calendar does, otherwise as the first calendar does
+func (c *CombinedCalendar) DayType(date time.Time) DayType {
+	dayType := Workday
+	for i, cal := range c.calendars {
+		t := cal.DayType(date)
+		if t == Holiday {
+			return Holiday
+		}
+		if i == 0 {
+			dayType = t
+		}
+	}
+	if len(c.calendars) == 0 {
+		return weekdayType(date)
+	}
+	return dayType
+}
+
+// calendarOf returns the first calendar that knows a festival
+func (c *CombinedCalendar) calendarOf(festival string) (Calendar, error) {
+	for _, cal := range c.calendars {
+		for _, name := range cal.Festivals() {
+			if name == festival {
+				return cal, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("festival %s not found", festival)
+}
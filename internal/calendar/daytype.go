@@ -0,0 +1,64 @@
+package calendar
+
+import "time"
+
+// DayType classifies a date for baseline selection
+type DayType int
+
+const (
+	Workday DayType = iota
+	Weekend
+	Holiday
+	MakeupWorkday // a weekend day made a workday to bridge a public holiday (调休)
+)
+
+// String returns the name of the day type
+func (t DayType) String() string {
+	switch t {
+	case Weekend:
+		return "weekend"
+	case Holiday:
+		return "holiday"
+	case MakeupWorkday:
+		return "make-up workday"
+	default:
+		return "workday"
+	}
+}
+
+// Working reports whether people work on days of the type
+func (t DayType) Working() bool {
+	return t == Workday || t == MakeupWorkday
+}
+
+// DayType classifies date by the official holiday schedule of its year if one is loaded.
+// Without a schedule, a date in the window of a festival that is a day off is a holiday.
+// Other dates are weekend or workday.
+func (c *LunarCalendar) DayType(date time.Time) DayType {
+	if schedule, exists := c.schedules[date.Year()]; exists {
+		if day, scheduled := schedule.Day(date); scheduled {
+			return day.Type
+		}
+	} else if c.dayOff(date) {
+		return Holiday
+	}
+	return weekdayType(date)
+}
+
+// dayOff reports whether date is in the window of a festival that is a public holiday
+func (c *LunarCalendar) dayOff(date time.Time) bool {
+	for _, day := range c.GetFestivalDays(date) {
+		if festival, _ := c.festival(day.Festival); festival.DayOff {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayType classifies date as weekend or workday by its weekday
+func weekdayType(date time.Time) DayType {
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return Weekend
+	}
+	return Workday
+}
@@ -0,0 +1,238 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// eventHorizon bounds how far ahead GetNextFestival looks for event occurrences
+const eventHorizon = 10
+
+// EventCalendar treats events, e.g. imported from an iCalendar file, as festivals.
+// Events with the same name are occurrences of the same festival, so a campaign is
+// compared with its previous editions, and each occurrence's window covers its days.
+type EventCalendar struct {
+	events []Event
+}
+
+// NewEventCalendar creates a calendar of the given events
+func NewEventCalendar(events ...Event) *EventCalendar {
+	return &EventCalendar{events: events}
+}
+
+// GetFestival returns the event starting on the day of date, the highest priority one if there are several
+func (c *EventCalendar) GetFestival(date time.Time) (string, bool) {
+	return festivalOn(c.GetFestivalDays(date))
+}
+
+// GetFestivalDay returns the event taking place on date, the first of GetFestivalDays
+func (c *EventCalendar) GetFestivalDay(date time.Time) (FestivalDay, bool) {
+	return firstFestivalDay(c.GetFestivalDays(date))
+}
+
+// GetFestivalDays returns all events taking place on date, by descending priority,
+// then the one that started last, then in the order they are defined
+func (c *EventCalendar) GetFestivalDays(date time.Time) []FestivalDay {
+	day := utcDay(date)
+	var occurrences []festivalOccurrence
+	for _, event := range c.events {
+		for _, start := range event.occurrences(day) {
+			occurrences = append(occurrences, festivalOccurrence{
+				festival: event.Name,
+				date:     start,
+				after:    event.Days - 1,
+				priority: event.Priority,
+			})
+		}
+	}
+	return festivalDays(day, occurrences)
+}
+
+// GetFestivalOccurrences returns the n most recent starts of an event before
+// currentDate, most recent first
+func (c *EventCalendar) GetFestivalOccurrences(currentDate time.Time, festival string, n int) ([]time.Time, error) {
+	today := utcDay(currentDate)
+	var starts []time.Time
+	found := false
+	for _, event := range c.events {
+		if event.Name != festival {
+			continue
+		}
+		found = true
+		for _, start := range event.occurrences(today) {
+			if start.Before(today) {
+				starts = append(starts, start)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("festival %s not found", festival)
+	}
+	if len(starts) < n {
+		return nil, fmt.Errorf("only %d occurrences of %s before %s", len(starts), festival, currentDate.Format("2006-01-02"))
+	}
+
+	sort.Slice(starts, func(i, j int) bool { return starts[i].After(starts[j]) })
+	occurrences := make([]time.Time, n)
+	for i := range occurrences {
+		occurrences[i] = time.Date(starts[i].Year(), starts[i].Month(), starts[i].Day(), 0, 0, 0, 0, currentDate.Location())
+	}
+	return occurrences, nil
+}
+
+// GetFestivalDayOccurrences returns the dates at the same offset from the n previous
+// starts of the event, most recent first
+func (c *EventCalendar) GetFestivalDayOccurrences(currentDate time.Time, day FestivalDay, n int) ([]time.Time, error) {
+	return festivalDayOccurrences(c, currentDate, day, n)
+}
+
+// GetNextFestival returns the next event to start and its date
+func (c *EventCalendar) GetNextFestival(currentDate time.Time) (string, time.Time, error) {
+	today := utcDay(currentDate)
+	var nextFestival string
+	var nextDate time.Time
+	for _, event := range c.events {
+		for _, start := range event.occurrences(today.AddDate(eventHorizon, 0, 0)) {
+			if start.Before(today) {
+				continue
+			}
+			if nextFestival == "" || start.Before(nextDate) {
+				nextFestival = event.Name
+				nextDate = start
+			}
+			break
+		}
+	}
+
+	if nextFestival == "" {
+		return "", time.Time{}, fmt.Errorf("no upcoming festivals found")
+	}
+
+	// Of events starting on the same day, the highest priority one is next
+	nextFestival, _ = c.GetFestival(nextDate)
+	return nextFestival, time.Date(nextDate.Year(), nextDate.Month(), nextDate.Day(), 0, 0, 0, 0, currentDate.Location()), nil
+}
+
+// Festivals returns the names of the events, once per name
+func (c *EventCalendar) Festivals() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, event := range c.events {
+		if !seen[event.Name] {
+			seen[event.Name] = true
+			names = append(names, event.Name)
+		}
+	}
+	return names
+}
+
+// DayType classifies date as weekend or workday; events are not days off
+func (c *EventCalendar) DayType(date time.Time) DayType {
+	return weekdayType(date)
+}
+
+// SetFestivalPriority sets the priority of all events with the given name over overlapping ones
+func (c *EventCalendar) SetFestivalPriority(festival string, priority int) error {
+	found := false
+	for i := range c.events {
+		if c.events[i].Name == festival {
+			c.events[i].Priority = priority
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("festival %s not found", festival)
+	}
+	return nil
+}
+
+// occurrences returns the days the event starts on up to and including until, in order
+func (e Event) occurrences(until time.Time) []time.Time {
+	if e.Recurrence == nil {
+		if e.Start.After(until) {
+			return nil
+		}
+		return []time.Time{e.Start}
+	}
+
+	r := e.Recurrence
+	var starts []time.Time
+	count := 0
+	for period := 0; ; period++ {
+		candidates, periodStart := r.candidates(e.Start, period)
+		if periodStart.After(until) || !r.Until.IsZero() && periodStart.After(r.Until) {
+			return starts
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(e.Start) {
+				continue
+			}
+			if candidate.After(until) || !r.Until.IsZero() && candidate.After(r.Until) || r.Count > 0 && count >= r.Count {
+				return starts
+			}
+			count++
+			if !e.isException(candidate) {
+				starts = append(starts, candidate)
+			}
+		}
+	}
+}
+
+// candidates returns the days a recurrence may start on in the given period after
+// start, and the first day of the period
+func (r *Recurrence) candidates(start time.Time, period int) ([]time.Time, time.Time) {
+	step := period * r.Interval
+	switch r.Frequency {
+	case "DAILY":
+		day := start.AddDate(0, 0, step)
+		return []time.Time{day}, day
+	case "WEEKLY":
+		day := start.AddDate(0, 0, 7*step)
+		return []time.Time{day}, day
+	case "MONTHLY":
+		month := time.Date(start.Year(), start.Month()+time.Month(step), 1, 0, 0, 0, 0, time.UTC)
+		return r.inMonths(start, month.Year(), []time.Month{month.Month()}), month
+	default:
+		year := start.Year() + step
+		months := r.ByMonth
+		if months == nil {
+			months = []time.Month{start.Month()}
+		}
+		return r.inMonths(start, year, months), time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// inMonths returns the candidate day in each of the months of a year: the BYDAY
+// weekday if set, otherwise the day of the month of start, skipping months without it
+func (r *Recurrence) inMonths(start time.Time, year int, months []time.Month) []time.Time {
+	var days []time.Time
+	for _, month := range months {
+		if r.ByDay != nil {
+			day := NthWeekday(month, r.ByDay.Weekday, r.ByDay.N)(year)
+			if day.Month() == month {
+				days = append(days, day)
+			}
+			continue
+		}
+		day := time.Date(year, month, start.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Month() == month {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (e Event) isException(day time.Time) bool {
+	for _, exception := range e.Exceptions {
+		if exception.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// utcDay returns the calendar day of t at midnight UTC
+func utcDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,110 @@
+package calendar
+
+import "time"
+
+// HolidayRule returns the date of a holiday in a Gregorian year, at midnight UTC
+type HolidayRule func(year int) time.Time
+
+// FixedDate is a holiday on the same date every year, e.g. Christmas on December 25
+func FixedDate(month time.Month, day int) HolidayRule {
+	return func(year int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// NthWeekday is a holiday on the nth weekday of a month, e.g. the 4th Thursday of
+// November. Negative n counts from the end of the month, -1 being the last.
+func NthWeekday(month time.Month, weekday time.Weekday, n int) HolidayRule {
+	return func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+			back := (int(last.Weekday()) - int(weekday) + 7) % 7
+			return last.AddDate(0, 0, -back+7*(n+1))
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		ahead := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, ahead+7*(n-1))
+	}
+}
+
+// Easter is Easter Sunday by the Gregorian computus
+func Easter() HolidayRule {
+	return func(year int) time.Time {
+		// Anonymous Gregorian algorithm (Meeus/Jones/Butcher)
+		a := year % 19
+		b, c := year/100, year%100
+		d, e := b/4, b%4
+		f := (b + 8) / 25
+		g := (b - f + 1) / 3
+		h := (19*a + b - d - g + 15) % 30
+		i, k := c/4, c%4
+		l := (32 + 2*e + 2*i - h - k) % 7
+		m := (a + 11*h + 22*l) / 451
+		month := (h + l - 7*m + 114) / 31
+		day := (h+l-7*m+114)%31 + 1
+		return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// DaysAfter is a holiday the given number of days after another, negative for before,
+// e.g. Black Friday one day after Thanksgiving
+func DaysAfter(rule HolidayRule, days int) HolidayRule {
+	return func(year int) time.Time {
+		return rule(year).AddDate(0, 0, days)
+	}
+}
+
+// GregorianHoliday is a holiday whose date in a Gregorian year is given by a rule
+type GregorianHoliday struct {
+	Name string
+	Rule HolidayRule
+	// The holiday affects traffic from WindowBefore days before to WindowAfter days after its day
+	WindowBefore int
+	WindowAfter  int
+	Priority     int  // higher priorities win when festivals overlap
+	DayOff       bool // a public holiday, so its day and the days of its window after it are holidays
+}
+
+// Common Gregorian holidays
+var (
+	NewYearsDay  = GregorianHoliday{Name: "New Year's Day", Rule: FixedDate(time.January, 1), DayOff: true}
+	GoodFriday   = GregorianHoliday{Name: "Good Friday", Rule: DaysAfter(Easter(), -2), DayOff: true}
+	EasterSunday = GregorianHoliday{Name: "Easter", Rule: Easter(), DayOff: true}
+	EasterMonday = GregorianHoliday{Name: "Easter Monday", Rule: DaysAfter(Easter(), 1), DayOff: true}
+	Thanksgiving = GregorianHoliday{Name: "Thanksgiving", Rule: NthWeekday(time.November, time.Thursday, 4), DayOff: true}
+	BlackFriday  = GregorianHoliday{Name: "Black Friday", Rule: DaysAfter(NthWeekday(time.November, time.Thursday, 4), 1)}
+	ChristmasDay = GregorianHoliday{Name: "Christmas", Rule: FixedDate(time.December, 25), WindowBefore: 1, DayOff: true}
+	BoxingDay    = GregorianHoliday{Name: "Boxing Day", Rule: FixedDate(time.December, 26), DayOff: true}
+)
+
+// GregorianCalendar handles holidays defined on the Gregorian calendar
+type GregorianCalendar struct {
+	yearlyCalendar
+}
+
+// NewGregorianCalendar creates a calendar of the given holidays
+func NewGregorianCalendar(holidays ...GregorianHoliday) *GregorianCalendar {
+	c := &GregorianCalendar{yearlyCalendar{yearOf: func(date time.Time) int { return date.Year() }}}
+	for _, holiday := range holidays {
+		rule := holiday.Rule
+		c.festivals = append(c.festivals, yearlyFestival{
+			name:     holiday.Name,
+			date:     func(year int) (time.Time, error) { return rule(year), nil },
+			before:   holiday.WindowBefore,
+			after:    holiday.WindowAfter,
+			priority: holiday.Priority,
+			dayOff:   holiday.DayOff,
+		})
+	}
+	return c
+}
+
+// NewUSCalendar creates a calendar of the holidays that shape traffic in the United States
+func NewUSCalendar() *GregorianCalendar {
+	return NewGregorianCalendar(NewYearsDay, EasterSunday, Thanksgiving, BlackFriday, ChristmasDay)
+}
+
+// NewEuropeanCalendar creates a calendar of the holidays that shape traffic in Europe
+func NewEuropeanCalendar() *GregorianCalendar {
+	return NewGregorianCalendar(NewYearsDay, GoodFriday, EasterSunday, EasterMonday, BlackFriday, ChristmasDay, BoxingDay)
+}
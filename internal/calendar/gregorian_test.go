@@ -0,0 +1,91 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHolidayRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule HolidayRule
+		year int
+		want time.Time
+	}{
+		{"Easter 2000", Easter(), 2000, time.Date(2000, 4, 23, 0, 0, 0, 0, time.UTC)},
+		{"Easter 2024", Easter(), 2024, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"Easter 2025", Easter(), 2025, time.Date(2025, 4, 20, 0, 0, 0, 0, time.UTC)},
+		{"Easter 2038", Easter(), 2038, time.Date(2038, 4, 25, 0, 0, 0, 0, time.UTC)},
+		{"Thanksgiving 2024", Thanksgiving.Rule, 2024, time.Date(2024, 11, 28, 0, 0, 0, 0, time.UTC)},
+		{"Thanksgiving 2025", Thanksgiving.Rule, 2025, time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)},
+		{"Black Friday 2024", BlackFriday.Rule, 2024, time.Date(2024, 11, 29, 0, 0, 0, 0, time.UTC)},
+		{"last Monday of May 2024", NthWeekday(time.May, time.Monday, -1), 2024, time.Date(2024, 5, 27, 0, 0, 0, 0, time.UTC)},
+		{"second to last Friday of March 2024", NthWeekday(time.March, time.Friday, -2), 2024, time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)},
+		{"first Monday of September 2025", NthWeekday(time.September, time.Monday, 1), 2025, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule(tt.year))
+		})
+	}
+}
+
+func TestGregorianCalendar(t *testing.T) {
+	c := NewUSCalendar()
+
+	festival, ok := c.GetFestival(time.Date(2024, 11, 29, 10, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Black Friday", festival)
+
+	day, ok := c.GetFestivalDay(time.Date(2024, 12, 24, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Christmas-1", day.String())
+	// Only festivals that are days off are holidays, from their day on
+	assert.Equal(t, Workday, c.DayType(time.Date(2024, 12, 24, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Holiday, c.DayType(time.Date(2024, 12, 25, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Workday, c.DayType(time.Date(2024, 12, 27, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Holiday, c.DayType(time.Date(2024, 11, 28, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Workday, c.DayType(time.Date(2024, 11, 29, 0, 0, 0, 0, time.Local)))
+
+	occurrences, err := c.GetFestivalOccurrences(time.Date(2025, 11, 27, 0, 0, 0, 0, time.Local), "Thanksgiving", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 11, 28, 0, 0, 0, 0, time.Local),
+		time.Date(2023, 11, 23, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+	_, err = c.GetFestivalOccurrences(time.Date(2025, 11, 27, 0, 0, 0, 0, time.Local), "春节", 1)
+	assert.Error(t, err)
+
+	festival, date, err := c.GetNextFestival(time.Date(2025, 12, 26, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "New Year's Day", festival)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), date)
+}
+
+func TestCombinedCalendar(t *testing.T) {
+	c := NewCombinedCalendar(NewEuropeanCalendar(), NewLunarCalendar())
+	assert.Contains(t, c.Festivals(), "Easter Monday")
+	assert.Contains(t, c.Festivals(), "春节")
+
+	day, ok := c.GetFestivalDay(time.Date(2026, 2, 19, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "春节+2", day.String())
+	occurrences, err := c.GetFestivalDayOccurrences(time.Date(2026, 2, 19, 0, 0, 0, 0, time.Local), day, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)}, occurrences)
+
+	// Easter Sunday 2025 was also a weekend day for the lunar calendar
+	assert.Equal(t, Holiday, c.DayType(time.Date(2025, 4, 20, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Weekend, c.DayType(time.Date(2025, 4, 26, 0, 0, 0, 0, time.Local)))
+
+	festival, date, err := c.GetNextFestival(time.Date(2025, 12, 27, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "New Year's Day", festival)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), date)
+
+	_, err = c.GetFestivalOccurrences(time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), "Thanksgiving", 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// hebrewEpoch is the fixed day number before 1 Tishrei of year 1 AM
+const hebrewEpoch = -1373429
+
+// HebrewFestival is a festival a fixed number of days after Rosh Hashanah, the first
+// day of the Hebrew year. Days counted back from the next Rosh Hashanah are negative.
+type HebrewFestival struct {
+	Name string
+	// Day is the day of the year, 1 for 1 Tishrei; zero or negative days count back from
+	// the next year's Rosh Hashanah, whose date does not depend on month lengths
+	Day int
+	// The festival affects traffic from WindowBefore days before to WindowAfter days after its day
+	WindowBefore int
+	WindowAfter  int
+	Priority     int  // higher priorities win when festivals overlap
+	DayOff       bool // a public holiday, so its day and the days of its window after it are holidays
+}
+
+// Common Hebrew festivals
+var (
+	RoshHashanah = HebrewFestival{Name: "Rosh Hashanah", Day: 1, WindowAfter: 1, DayOff: true}
+	YomKippur    = HebrewFestival{Name: "Yom Kippur", Day: 10, DayOff: true}
+	Sukkot       = HebrewFestival{Name: "Sukkot", Day: 15, WindowAfter: 6, DayOff: true}
+	// 15 Nisan is always 163 days before the next 1 Tishrei
+	Passover = HebrewFestival{Name: "Passover", Day: -163, WindowAfter: 6, DayOff: true}
+)
+
+// HebrewCalendar handles festivals of the Hebrew calendar
+type HebrewCalendar struct {
+	yearlyCalendar
+}
+
+// NewHebrewCalendar creates a calendar of Rosh Hashanah, Yom Kippur, Sukkot and Passover
+func NewHebrewCalendar() *HebrewCalendar {
+	return NewHebrewCalendarOf(RoshHashanah, YomKippur, Sukkot, Passover)
+}
+
+// NewHebrewCalendarOf creates a calendar of the given festivals
+func NewHebrewCalendarOf(festivals ...HebrewFestival) *HebrewCalendar {
+	c := &HebrewCalendar{yearlyCalendar{yearOf: hebrewYear}}
+	for _, festival := range festivals {
+		day := festival.Day
+		c.festivals = append(c.festivals, yearlyFestival{
+			name: festival.Name,
+			date: func(year int) (time.Time, error) {
+				if year < 2 {
+					return time.Time{}, fmt.Errorf("hebrew year %d out of range", year)
+				}
+				if day > 0 {
+					return RoshHashanahDate(year).AddDate(0, 0, day-1), nil
+				}
+				return RoshHashanahDate(year+1).AddDate(0, 0, day), nil
+			},
+			before:   festival.WindowBefore,
+			after:    festival.WindowAfter,
+			priority: festival.Priority,
+			dayOff:   festival.DayOff,
+		})
+	}
+	return c
+}
+
+// RoshHashanahDate returns the Gregorian day of 1 Tishrei of a Hebrew year at midnight UTC
+func RoshHashanahDate(year int) time.Time {
+	return fromRataDie(hebrewEpoch + hebrewElapsedDays(year) + 1)
+}
+
+// hebrewYear returns the Hebrew year containing the calendar day of date
+func hebrewYear(date time.Time) int {
+	year := date.Year() + 3761
+	if utcDay(date).Before(RoshHashanahDate(year)) {
+		return year - 1
+	}
+	return year
+}
+
+// hebrewLeapYear reports whether a Hebrew year has the extra month Adar I
+func hebrewLeapYear(year int) bool {
+	return (7*year+1)%19 < 7
+}
+
+// hebrewElapsedDays returns the days from the epoch to 1 Tishrei of a year, from the
+// molad (mean new moon) of Tishrei and the postponement rules (dehiyyot)
+func hebrewElapsedDays(year int) int {
+	monthsElapsed := 235*((year-1)/19) + 12*((year-1)%19) + (7*((year-1)%19)+1)/19
+	partsElapsed := 204 + 793*(monthsElapsed%1080)
+	hoursElapsed := 5 + 12*monthsElapsed + 793*(monthsElapsed/1080) + partsElapsed/1080
+	day := 1 + 29*monthsElapsed + hoursElapsed/24
+	parts := 1080*(hoursElapsed%24) + partsElapsed%1080
+
+	// Molad at or after noon, or rules GaTaRaD and BeTUTaKPaT
+	if parts >= 19440 ||
+		day%7 == 2 && parts >= 9924 && !hebrewLeapYear(year) ||
+		day%7 == 1 && parts >= 16789 && hebrewLeapYear(year-1) {
+		day++
+	}
+	// Rosh Hashanah never falls on Sunday, Wednesday or Friday
+	if day%7 == 0 || day%7 == 3 || day%7 == 5 {
+		day++
+	}
+	return day
+}
@@ -0,0 +1,49 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoshHashanahDate(t *testing.T) {
+	tests := map[int]time.Time{
+		5760: time.Date(1999, 9, 11, 0, 0, 0, 0, time.UTC),
+		5783: time.Date(2022, 9, 26, 0, 0, 0, 0, time.UTC),
+		5784: time.Date(2023, 9, 16, 0, 0, 0, 0, time.UTC),
+		5785: time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
+		5786: time.Date(2025, 9, 23, 0, 0, 0, 0, time.UTC),
+		5787: time.Date(2026, 9, 12, 0, 0, 0, 0, time.UTC),
+	}
+	for year, want := range tests {
+		assert.Equal(t, want, RoshHashanahDate(year), year)
+	}
+}
+
+func TestHebrewCalendar(t *testing.T) {
+	c := NewHebrewCalendar()
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 10, 4, 0, 0, 0, 0, time.Local), "Rosh Hashanah+1"},
+		{time.Date(2024, 10, 12, 0, 0, 0, 0, time.Local), "Yom Kippur"},
+		{time.Date(2024, 10, 17, 0, 0, 0, 0, time.Local), "Sukkot"},
+		{time.Date(2024, 4, 23, 0, 0, 0, 0, time.Local), "Passover"},
+		{time.Date(2025, 4, 13, 0, 0, 0, 0, time.Local), "Passover"},
+	}
+	for _, tt := range tests {
+		day, ok := c.GetFestivalDay(tt.date)
+		assert.True(t, ok, tt.date.Format("2006-01-02"))
+		assert.Equal(t, tt.want, day.String())
+		assert.Equal(t, Holiday, c.DayType(tt.date))
+	}
+
+	occurrences, err := c.GetFestivalOccurrences(time.Date(2025, 9, 23, 0, 0, 0, 0, time.Local), "Rosh Hashanah", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 10, 3, 0, 0, 0, 0, time.Local),
+		time.Date(2023, 9, 16, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+}
@@ -0,0 +1,101 @@
+package calendar
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ScheduledDay is a public holiday or make-up workday of an official holiday schedule
+type ScheduledDay struct {
+	Date time.Time
+	Type DayType // Holiday or MakeupWorkday
+	Name string  // the holiday the day belongs to, e.g. 春节
+}
+
+// HolidaySchedule is the official schedule of public holidays and make-up workdays of a
+// country for one year. Days not in the schedule follow the regular week.
+type HolidaySchedule struct {
+	Country string
+	Year    int
+	days    map[string]ScheduledDay
+}
+
+// LoadHolidaySchedule reads the schedule of a country and year from <dir>/<country>_<year>.csv
+func LoadHolidaySchedule(dir, country string, year int) (*HolidaySchedule, error) {
+	filename := filepath.Join(dir, fmt.Sprintf("%s_%d.csv", country, year))
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open holiday schedule: %w", err)
+	}
+	defer file.Close()
+	return ParseHolidaySchedule(file, country, year)
+}
+
+// ParseHolidaySchedule reads a schedule with one "YYYYMMDD,holiday|workday,name" row per
+// day, where workday marks a make-up workday on a weekend
+func ParseHolidaySchedule(r io.Reader, country string, year int) (*HolidaySchedule, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holiday schedule: %w", err)
+	}
+
+	schedule := &HolidaySchedule{Country: country, Year: year, days: make(map[string]ScheduledDay)}
+	for i, record := range records {
+		date, err := time.Parse("20060102", record[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse date in row %d: %w", i+1, err)
+		}
+		if date.Year() != year {
+			return nil, fmt.Errorf("date %s in row %d is not in %d", record[0], i+1, year)
+		}
+
+		var dayType DayType
+		switch record[1] {
+		case "holiday":
+			dayType = Holiday
+		case "workday":
+			dayType = MakeupWorkday
+		default:
+			return nil, fmt.Errorf("unknown day type %q in row %d", record[1], i+1)
+		}
+
+		if _, exists := schedule.days[record[0]]; exists {
+			return nil, fmt.Errorf("duplicate date %s in row %d", record[0], i+1)
+		}
+		schedule.days[record[0]] = ScheduledDay{Date: date, Type: dayType, Name: record[2]}
+	}
+	return schedule, nil
+}
+
+// Day returns the scheduled holiday or make-up workday on the calendar day of date
+func (s *HolidaySchedule) Day(date time.Time) (ScheduledDay, bool) {
+	day, exists := s.days[date.Format("20060102")]
+	return day, exists
+}
+
+// AddHolidaySchedule makes the official schedule decide the day type of dates in its year,
+// replacing an earlier schedule of the same year
+func (c *LunarCalendar) AddHolidaySchedule(schedule *HolidaySchedule) {
+	if c.schedules == nil {
+		c.schedules = make(map[int]*HolidaySchedule)
+	}
+	c.schedules[schedule.Year] = schedule
+}
+
+// LoadHolidaySchedules loads the schedules of a country for the given years from dir
+func (c *LunarCalendar) LoadHolidaySchedules(dir, country string, years ...int) error {
+	for _, year := range years {
+		schedule, err := LoadHolidaySchedule(dir, country, year)
+		if err != nil {
+			return err
+		}
+		c.AddHolidaySchedule(schedule)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHolidaySchedule(t *testing.T) {
+	c := NewLunarCalendar()
+	assert.NoError(t, c.LoadHolidaySchedules("../../data/holidays", "CN", 2024, 2025))
+
+	tests := []struct {
+		date time.Time
+		want DayType
+	}{
+		{time.Date(2024, 2, 4, 0, 0, 0, 0, time.Local), MakeupWorkday}, // Sunday before Spring Festival
+		{time.Date(2024, 2, 9, 0, 0, 0, 0, time.Local), Workday},       // New Year's Eve is not a public holiday in 2024
+		{time.Date(2024, 2, 12, 0, 0, 0, 0, time.Local), Holiday},
+		{time.Date(2024, 2, 24, 0, 0, 0, 0, time.Local), Weekend}, // Lantern Festival is not a public holiday
+		{time.Date(2025, 10, 8, 0, 0, 0, 0, time.Local), Holiday},
+		{time.Date(2025, 10, 11, 0, 0, 0, 0, time.Local), MakeupWorkday},
+		{time.Date(2025, 10, 13, 0, 0, 0, 0, time.Local), Workday},
+		// Years without a schedule fall back to the festival windows
+		{time.Date(2026, 2, 18, 0, 0, 0, 0, time.Local), Holiday},
+		{time.Date(2026, 2, 28, 0, 0, 0, 0, time.Local), Weekend},
+		{time.Date(2026, 2, 10, 0, 0, 0, 0, time.Local), Workday}, // Little New Year is not a day off
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, c.DayType(tt.date), tt.date.Format("2006-01-02"))
+	}
+
+	assert.NoError(t, c.LoadHolidaySchedules("../../data/holidays", "CN", 2026))
+	assert.Equal(t, MakeupWorkday, c.DayType(time.Date(2026, 2, 28, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Holiday, c.DayType(time.Date(2026, 2, 23, 0, 0, 0, 0, time.Local)))
+
+	_, err := LoadHolidaySchedule("../../data/holidays", "CN", 1999)
+	assert.Error(t, err)
+}
+
+func TestParseHolidaySchedule(t *testing.T) {
+	schedule, err := ParseHolidaySchedule(strings.NewReader("20240101,holiday,元旦\n"), "CN", 2024)
+	assert.NoError(t, err)
+	day, ok := schedule.Day(time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, ScheduledDay{Date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Type: Holiday, Name: "元旦"}, day)
+
+	for _, input := range []string{
+		"20240101,holiday\n",
+		"2024-01-01,holiday,元旦\n",
+		"20250101,holiday,元旦\n",
+		"20240101,vacation,元旦\n",
+		"20240101,holiday,元旦\n20240101,holiday,元旦\n",
+	} {
+		_, err := ParseHolidaySchedule(strings.NewReader(input), "CN", 2024)
+		assert.Error(t, err, input)
+	}
+}
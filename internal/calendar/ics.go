@@ -0,0 +1,339 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a named event lasting one or more days, e.g. a marketing campaign, that
+// may recur
+type Event struct {
+	Name       string
+	Start      time.Time // first day, at midnight UTC
+	Days       int       // number of days the event lasts
+	Recurrence *Recurrence
+	Exceptions []time.Time // days on which a recurring event does not start
+	Priority   int         // higher priorities win when events overlap
+}
+
+// Recurrence is the subset of an iCalendar RRULE supported for events
+type Recurrence struct {
+	Frequency string // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval  int
+	Count     int       // 0 for no limit
+	Until     time.Time // zero for no limit
+	ByMonth   []time.Month
+	// ByDay picks the nth weekday of the month for monthly and yearly rules, e.g. 4TH;
+	// negative N counts from the end of the month
+	ByDay *NthDay
+}
+
+// NthDay is the nth weekday of a month
+type NthDay struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// LoadICS reads the events of an iCalendar file into an event calendar
+func LoadICS(path string) (*EventCalendar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open calendar file: %w", err)
+	}
+	defer file.Close()
+
+	events, err := ParseICS(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventCalendar(events...), nil
+}
+
+// ParseICS reads the VEVENTs of an iCalendar stream. Date-times are taken at their
+// calendar day in their own time zone; an event lasts from the day of DTSTART to the
+// day before an all-day DTEND, or the day of a timed one.
+func ParseICS(r io.Reader) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	var events []Event
+	var properties map[string]icsProperty
+	var exceptions []icsProperty
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			properties = make(map[string]icsProperty)
+			exceptions = nil
+		case line == "END:VEVENT":
+			if properties == nil {
+				return nil, fmt.Errorf("END:VEVENT without BEGIN:VEVENT")
+			}
+			event, err := parseEvent(properties, exceptions)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+			properties = nil
+		case properties != nil:
+			property, err := parseProperty(line)
+			if err != nil {
+				return nil, err
+			}
+			if property.name == "EXDATE" {
+				exceptions = append(exceptions, property)
+			} else {
+				properties[property.name] = property
+			}
+		}
+	}
+	if properties != nil {
+		return nil, fmt.Errorf("unterminated VEVENT")
+	}
+	return events, nil
+}
+
+// icsProperty is a content line such as DTSTART;VALUE=DATE:20241111
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfoldLines joins the continuation lines of an iCalendar stream, which start with a space or tab
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) (icsProperty, error) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return icsProperty{}, fmt.Errorf("invalid content line %q", line)
+	}
+	parts := strings.Split(line[:colon], ";")
+	property := icsProperty{name: strings.ToUpper(parts[0]), params: make(map[string]string), value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return property, nil
+}
+
+func parseEvent(properties map[string]icsProperty, exceptions []icsProperty) (Event, error) {
+	summary, exists := properties["SUMMARY"]
+	if !exists || summary.value == "" {
+		return Event{}, fmt.Errorf("event without SUMMARY")
+	}
+	event := Event{Name: unescapeText(summary.value), Days: 1}
+
+	dtstart, exists := properties["DTSTART"]
+	if !exists {
+		return Event{}, fmt.Errorf("event %s without DTSTART", event.Name)
+	}
+	start, allDay, err := parseDate(dtstart)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to parse DTSTART of %s: %w", event.Name, err)
+	}
+	event.Start = start
+
+	if dtend, exists := properties["DTEND"]; exists {
+		end, endAllDay, err := parseDate(dtend)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to parse DTEND of %s: %w", event.Name, err)
+		}
+		// The DTEND of an all-day event is the day after it
+		event.Days = daysBetween(start, end)
+		if !endAllDay {
+			event.Days++
+		}
+	} else if duration, exists := properties["DURATION"]; exists {
+		days, err := parseDurationDays(duration.value)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to parse DURATION of %s: %w", event.Name, err)
+		}
+		event.Days = days
+		if !allDay {
+			event.Days++
+		}
+	}
+	if event.Days < 1 {
+		event.Days = 1
+	}
+
+	if priority, exists := properties["PRIORITY"]; exists {
+		// iCalendar priorities run from 1 (highest) to 9 (lowest), 0 being undefined
+		p, err := strconv.Atoi(priority.value)
+		if err != nil || p < 0 || p > 9 {
+			return Event{}, fmt.Errorf("invalid PRIORITY %q of %s", priority.value, event.Name)
+		}
+		if p > 0 {
+			event.Priority = 10 - p
+		}
+	}
+
+	if rrule, exists := properties["RRULE"]; exists {
+		recurrence, err := parseRecurrence(rrule.value)
+		if err != nil {
+			return Event{}, fmt.Errorf("failed to parse RRULE of %s: %w", event.Name, err)
+		}
+		event.Recurrence = recurrence
+	}
+
+	for _, exdate := range exceptions {
+		for _, value := range strings.Split(exdate.value, ",") {
+			date, _, err := parseDate(icsProperty{params: exdate.params, value: value})
+			if err != nil {
+				return Event{}, fmt.Errorf("failed to parse EXDATE of %s: %w", event.Name, err)
+			}
+			event.Exceptions = append(event.Exceptions, date)
+		}
+	}
+	return event, nil
+}
+
+// parseDate returns the calendar day of a DATE or DATE-TIME value at midnight UTC, and
+// whether the value is a date
+func parseDate(property icsProperty) (time.Time, bool, error) {
+	value := property.value
+	if property.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		date, err := time.Parse("20060102", value)
+		return date, true, err
+	}
+
+	loc := time.UTC
+	if tzid, exists := property.params["TZID"]; exists {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown time zone %s: %w", tzid, err)
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(value, "Z"), loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), false, nil
+}
+
+// parseDurationDays returns the whole days of a duration such as P3D or P1W
+func parseDurationDays(value string) (int, error) {
+	value = strings.TrimPrefix(value, "+")
+	switch {
+	case strings.HasPrefix(value, "P") && strings.HasSuffix(value, "W"):
+		weeks, err := strconv.Atoi(value[1 : len(value)-1])
+		return 7 * weeks, err
+	case strings.HasPrefix(value, "P") && !strings.Contains(value, "T"):
+		days, err := strconv.Atoi(strings.TrimSuffix(value[1:], "D"))
+		return days, err
+	case strings.HasPrefix(value, "P"):
+		// Durations of hours or minutes, possibly with days, e.g. P1DT12H
+		days := 0
+		if d, _, ok := strings.Cut(value[1:], "D"); ok {
+			var err error
+			if days, err = strconv.Atoi(d); err != nil {
+				return 0, err
+			}
+		}
+		return days, nil
+	default:
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+func parseRecurrence(value string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Frequency = strings.ToUpper(val)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(val)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(val)
+		case "UNTIL":
+			r.Until, _, err = parseDate(icsProperty{value: val})
+		case "BYMONTH":
+			for _, m := range strings.Split(val, ",") {
+				month, err := strconv.Atoi(m)
+				if err != nil || month < 1 || month > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", val)
+				}
+				r.ByMonth = append(r.ByMonth, time.Month(month))
+			}
+			sort.Slice(r.ByMonth, func(i, j int) bool { return r.ByMonth[i] < r.ByMonth[j] })
+		case "BYDAY":
+			r.ByDay, err = parseNthDay(val)
+		case "WKST":
+			// Only matters for weekly rules with several days, which are not supported
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+
+	switch r.Frequency {
+	case "DAILY", "WEEKLY":
+		if r.ByDay != nil || r.ByMonth != nil {
+			return nil, fmt.Errorf("BYDAY and BYMONTH are only supported for monthly and yearly rules")
+		}
+	case "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported frequency %q", r.Frequency)
+	}
+	if r.Interval < 1 || r.Count < 0 {
+		return nil, fmt.Errorf("invalid interval %d or count %d", r.Interval, r.Count)
+	}
+	return r, nil
+}
+
+// parseNthDay parses a single BYDAY value with an ordinal, such as 4TH or -1MO
+func parseNthDay(value string) (*NthDay, error) {
+	if len(value) < 3 || strings.Contains(value, ",") {
+		return nil, fmt.Errorf("only a single weekday with an ordinal is supported, got %q", value)
+	}
+	weekday, exists := icsWeekdays[strings.ToUpper(value[len(value)-2:])]
+	if !exists {
+		return nil, fmt.Errorf("invalid weekday in %q", value)
+	}
+	n, err := strconv.Atoi(value[:len(value)-2])
+	if err != nil || n == 0 || n < -5 || n > 5 {
+		return nil, fmt.Errorf("invalid ordinal in %q", value)
+	}
+	return &NthDay{Weekday: weekday, N: n}, nil
+}
+
+// unescapeText resolves the backslash escapes of an iCalendar TEXT value
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ").Replace(value)
+}
@@ -0,0 +1,160 @@
+package calendar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const campaignsICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+SUMMARY:Singles Day Sale
+DTSTART;VALUE=DATE:20221110
+DTEND;VALUE=DATE:20221113
+RRULE:FREQ=YEARLY
+EXDATE;VALUE=DATE:20231110
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Product Launch
+DTSTART;TZID=Asia/Shanghai:20240910T100000
+DTEND;TZID=Asia/Shanghai:20240910T120000
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Product Launch
+DTSTART:20230912T170000Z
+DURATION:PT2H
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Thanksgiving Sale\, 
+ US only
+DTSTART;VALUE=DATE:20231123
+RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+SUMMARY:Payday Promo
+DTSTART;VALUE=DATE:20240126
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20240630
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParseICS(t *testing.T) {
+	events, err := ParseICS(strings.NewReader(strings.ReplaceAll(campaignsICS, "\n", "\r\n")))
+	assert.NoError(t, err)
+	if !assert.Len(t, events, 5) {
+		return
+	}
+	assert.Equal(t, Event{
+		Name:       "Singles Day Sale",
+		Start:      time.Date(2022, 11, 10, 0, 0, 0, 0, time.UTC),
+		Days:       3,
+		Recurrence: &Recurrence{Frequency: "YEARLY", Interval: 1},
+		Exceptions: []time.Time{time.Date(2023, 11, 10, 0, 0, 0, 0, time.UTC)},
+	}, events[0])
+	assert.Equal(t, 1, events[1].Days)
+	assert.Equal(t, "Thanksgiving Sale, US only", events[3].Name)
+	assert.Equal(t, &NthDay{Weekday: time.Thursday, N: 4}, events[3].Recurrence.ByDay)
+
+	for _, input := range []string{
+		"BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:20240101\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART;VALUE=DATE:20240101\nRRULE:FREQ=HOURLY\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART;VALUE=DATE:20240101\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE\nEND:VEVENT\n",
+		"BEGIN:VEVENT\nSUMMARY:x\nDTSTART;VALUE=DATE:20240101\n",
+	} {
+		_, err := ParseICS(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestEventCalendar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "campaigns.ics")
+	assert.NoError(t, os.WriteFile(path, []byte(campaignsICS), 0o644))
+	c, err := LoadICS(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Singles Day Sale", "Product Launch", "Thanksgiving Sale, US only", "Payday Promo"}, c.Festivals())
+
+	// Day 3 of the sale compares with day 3 of the previous edition, skipping the cancelled 2023 one
+	day, ok := c.GetFestivalDay(time.Date(2024, 11, 12, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Singles Day Sale+2", day.String())
+	occurrences, err := c.GetFestivalDayOccurrences(time.Date(2024, 11, 12, 0, 0, 0, 0, time.Local), day, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2022, 11, 12, 0, 0, 0, 0, time.Local)}, occurrences)
+	_, err = c.GetFestivalOccurrences(time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local), "Singles Day Sale", 2)
+	assert.Error(t, err)
+	_, ok = c.GetFestivalDay(time.Date(2023, 11, 11, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+
+	// Separate events with the same name are editions of one festival
+	occurrences, err = c.GetFestivalOccurrences(time.Date(2024, 9, 10, 0, 0, 0, 0, time.Local), "Product Launch", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2023, 9, 12, 0, 0, 0, 0, time.Local)}, occurrences)
+
+	festival, ok := c.GetFestival(time.Date(2025, 11, 27, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Thanksgiving Sale, US only", festival)
+	_, ok = c.GetFestival(time.Date(2026, 11, 26, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok, "COUNT limits the sale to three years")
+
+	for _, date := range []time.Time{
+		time.Date(2024, 3, 29, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 6, 28, 0, 0, 0, 0, time.Local),
+	} {
+		festival, _ := c.GetFestival(date)
+		assert.Equal(t, "Payday Promo", festival, date.Format("2006-01-02"))
+	}
+	_, ok = c.GetFestival(time.Date(2024, 7, 26, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok, "UNTIL ends the promo in June")
+
+	festival, date, err := c.GetNextFestival(time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "Payday Promo", festival)
+	assert.Equal(t, time.Date(2024, 6, 28, 0, 0, 0, 0, time.Local), date)
+
+	assert.Equal(t, Weekend, c.DayType(time.Date(2024, 11, 10, 0, 0, 0, 0, time.Local)))
+}
+
+func TestOverlappingFestivals(t *testing.T) {
+	events, err := ParseICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:Mooncake Sale\nDTSTART;VALUE=DATE:20240915\nDTEND;VALUE=DATE:20240918\nPRIORITY:1\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Autumn Promo\nDTSTART;VALUE=DATE:20240917\nEND:VEVENT\n"))
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, 9, events[0].Priority)
+	}
+	_, err = ParseICS(strings.NewReader("BEGIN:VEVENT\nSUMMARY:x\nDTSTART;VALUE=DATE:20240101\nPRIORITY:10\nEND:VEVENT\n"))
+	assert.Error(t, err)
+
+	lunar := NewLunarCalendar()
+	campaigns := NewEventCalendar(events...)
+	c := NewCombinedCalendar(lunar, campaigns)
+	midAutumn := time.Date(2024, 9, 17, 0, 0, 0, 0, time.Local)
+
+	// The campaign outranks the festival, which comes before the promo of equal priority
+	assert.Equal(t, []FestivalDay{
+		{Festival: "Mooncake Sale", Offset: 2, Priority: 9},
+		{Festival: "中秋节"},
+		{Festival: "Autumn Promo"},
+	}, c.GetFestivalDays(midAutumn))
+	festival, ok := c.GetFestival(midAutumn)
+	assert.True(t, ok)
+	assert.Equal(t, "中秋节", festival, "the sale started two days before")
+	festival, date, err := c.GetNextFestival(time.Date(2024, 9, 16, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "中秋节", festival)
+	assert.Equal(t, midAutumn, date)
+
+	assert.NoError(t, lunar.SetFestivalPriority("中秋节", 10))
+	day, ok := c.GetFestivalDay(midAutumn)
+	assert.True(t, ok)
+	assert.Equal(t, FestivalDay{Festival: "中秋节", Priority: 10}, day)
+	assert.Error(t, lunar.SetFestivalPriority("Mooncake Sale", 1))
+	assert.NoError(t, campaigns.SetFestivalPriority("Autumn Promo", 20))
+	festival, _ = c.GetFestival(midAutumn)
+	assert.Equal(t, "Autumn Promo", festival)
+}
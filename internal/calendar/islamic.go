@@ -0,0 +1,99 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// islamicEpoch is the fixed day number of 1 Muharram 1 AH (July 16, 622 Julian)
+const islamicEpoch = 227015
+
+// HijriDate is a date in the tabular Islamic calendar
+type HijriDate struct {
+	Year  int
+	Month int // 1 to 12
+	Day   int // 1 to 30
+}
+
+// String returns the date as "1445-10-01"
+func (d HijriDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// FromHijri returns midnight in loc of the Gregorian day of a tabular Hijri date. The
+// tabular calendar alternates 30 and 29 day months with 11 leap years in 30; observed
+// dates depend on moon sighting and may differ by a day or two.
+func FromHijri(d HijriDate, loc *time.Location) (time.Time, error) {
+	if d.Year < 1 || d.Month < 1 || d.Month > 12 || d.Day < 1 || d.Day > 30 {
+		return time.Time{}, fmt.Errorf("invalid Hijri date %s", d)
+	}
+	day := fromRataDie(islamicEpoch - 1 + (d.Year-1)*354 + (3+11*d.Year)/30 + 29*(d.Month-1) + d.Month/2 + d.Day)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// ToHijri converts the calendar day of date to a tabular Hijri date
+func ToHijri(date time.Time) HijriDate {
+	rd := rataDie(date)
+	year := (30*(rd-islamicEpoch) + 10646) / 10631
+	start := func(month int) int {
+		return islamicEpoch - 1 + (year-1)*354 + (3+11*year)/30 + 29*(month-1) + month/2 + 1
+	}
+	// The leap day at the end of a year belongs to the twelfth month
+	month := int(math.Min(12, math.Ceil(float64(rd-29-start(1))/29.5)+1))
+	return HijriDate{Year: year, Month: month, Day: rd - start(month) + 1}
+}
+
+// IslamicFestival is a festival on a fixed day of the Hijri calendar
+type IslamicFestival struct {
+	Name  string
+	Month int
+	Day   int
+	// The festival affects traffic from WindowBefore days before to WindowAfter days after its day
+	WindowBefore int
+	WindowAfter  int
+	Priority     int  // higher priorities win when festivals overlap
+	DayOff       bool // a public holiday, so its day and the days of its window after it are holidays
+}
+
+// Common Islamic festivals
+var (
+	Ramadan   = IslamicFestival{Name: "Ramadan", Month: 9, Day: 1, WindowAfter: 28}
+	EidAlFitr = IslamicFestival{Name: "Eid al-Fitr", Month: 10, Day: 1, WindowAfter: 2, DayOff: true}
+	EidAlAdha = IslamicFestival{Name: "Eid al-Adha", Month: 12, Day: 10, WindowAfter: 3, DayOff: true}
+)
+
+// IslamicCalendar handles festivals of the tabular Islamic calendar, shifted by an
+// offset in days to match the dates observed in a region
+type IslamicCalendar struct {
+	yearlyCalendar
+}
+
+// NewIslamicCalendar creates a calendar of Ramadan, Eid al-Fitr and Eid al-Adha, with
+// dates moved offset days later than the tabular calendar (earlier if negative)
+func NewIslamicCalendar(offset int) *IslamicCalendar {
+	return NewIslamicCalendarOf(offset, Ramadan, EidAlFitr, EidAlAdha)
+}
+
+// NewIslamicCalendarOf creates a calendar of the given festivals, with dates moved
+// offset days later than the tabular calendar
+func NewIslamicCalendarOf(offset int, festivals ...IslamicFestival) *IslamicCalendar {
+	c := &IslamicCalendar{yearlyCalendar{yearOf: func(date time.Time) int {
+		return ToHijri(date.AddDate(0, 0, -offset)).Year
+	}}}
+	for _, festival := range festivals {
+		month, day := festival.Month, festival.Day
+		c.festivals = append(c.festivals, yearlyFestival{
+			name: festival.Name,
+			date: func(year int) (time.Time, error) {
+				date, err := FromHijri(HijriDate{Year: year, Month: month, Day: day}, time.UTC)
+				return date.AddDate(0, 0, offset), err
+			},
+			before:   festival.WindowBefore,
+			after:    festival.WindowAfter,
+			priority: festival.Priority,
+			dayOff:   festival.DayOff,
+		})
+	}
+	return c
+}
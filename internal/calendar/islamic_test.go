@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHijriConversion(t *testing.T) {
+	date, err := FromHijri(HijriDate{Year: 1445, Month: 10, Day: 1}, time.Local)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 4, 10, 0, 0, 0, 0, time.Local), date)
+	_, err = FromHijri(HijriDate{Year: 1445, Month: 13, Day: 1}, time.Local)
+	assert.Error(t, err)
+
+	// Every day converts back to itself, including the leap days ending 30-day twelfth months
+	for day := time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC); day.Year() <= 2100; day = day.AddDate(0, 0, 1) {
+		hijri := ToHijri(day)
+		back, err := FromHijri(hijri, time.UTC)
+		if !assert.NoError(t, err) || !assert.Equal(t, day, back, hijri.String()) {
+			return
+		}
+	}
+}
+
+func TestIslamicCalendar(t *testing.T) {
+	c := NewIslamicCalendar(0)
+	day, ok := c.GetFestivalDay(time.Date(2024, 3, 20, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Ramadan+9", day.String())
+	assert.Equal(t, Workday, c.DayType(time.Date(2024, 3, 20, 0, 0, 0, 0, time.Local)))
+
+	// Islamic years are 11 days shorter, so the previous Eid is not a Gregorian year earlier
+	occurrences, err := c.GetFestivalOccurrences(time.Date(2024, 4, 10, 0, 0, 0, 0, time.Local), "Eid al-Fitr", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 4, 22, 0, 0, 0, 0, time.Local),
+		time.Date(2022, 5, 3, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+
+	// An offset matches the dates observed by moon sighting
+	observed := NewIslamicCalendar(-1)
+	festival, ok := observed.GetFestival(time.Date(2023, 4, 21, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Eid al-Fitr", festival)
+	assert.Equal(t, Holiday, observed.DayType(time.Date(2023, 4, 23, 0, 0, 0, 0, time.Local)))
+
+	festival, date, err := c.GetNextFestival(time.Date(2025, 4, 3, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "Eid al-Adha", festival)
+	assert.Equal(t, time.Date(2025, 6, 7, 0, 0, 0, 0, time.Local), date)
+}
@@ -2,66 +2,168 @@ package calendar
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
+// LunarFestival is a festival on a fixed day of the lunar calendar, or on a solar term
+type LunarFestival struct {
+	Name        string // Chinese name, used in notifications
+	EnglishName string
+	Month       int
+	Day         int    // 0 for the last day of the month
+	SolarTerm   string // if set, the festival falls on this solar term instead of Month and Day
+	// The festival affects traffic from WindowBefore days before to WindowAfter days after its day
+	WindowBefore int
+	WindowAfter  int
+	Priority     int  // higher priorities win when festivals overlap
+	DayOff       bool // a public holiday, so days in its window are holidays without a schedule
+}
+
 // LunarCalendar handles lunar calendar related operations
 type LunarCalendar struct {
-	festivalMap map[string]time.Time
+	festivals []LunarFestival
+	schedules map[int]*HolidaySchedule // official holiday schedules by year
 }
 
 // NewLunarCalendar creates a new lunar calendar instance
 func NewLunarCalendar() *LunarCalendar {
-	return &LunarCalendar{
-		festivalMap: map[string]time.Time{
-			"春节":  time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local),
-			"元宵节": time.Date(2024, 2, 24, 0, 0, 0, 0, time.Local),
-			"端午节": time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local),
-			"中秋节": time.Date(2024, 9, 17, 0, 0, 0, 0, time.Local),
-		},
+	festivals := make([]LunarFestival, len(traditionalFestivals))
+	copy(festivals, traditionalFestivals)
+	return &LunarCalendar{festivals: festivals}
+}
+
+// traditionalFestivals are the Chinese traditional festivals known to a new calendar
+var traditionalFestivals = []LunarFestival{
+	// New Year's Eve through the seventh day
+	{Name: "春节", EnglishName: "Spring Festival", Month: 1, Day: 1, WindowBefore: 1, WindowAfter: 6, DayOff: true},
+	{Name: "元宵节", EnglishName: "Lantern Festival", Month: 1, Day: 15},
+	{Name: "清明节", EnglishName: "Qingming Festival", SolarTerm: "清明", DayOff: true},
+	{Name: "端午节", EnglishName: "Dragon Boat Festival", Month: 5, Day: 5, DayOff: true},
+	{Name: "七夕", EnglishName: "Qixi Festival", Month: 7, Day: 7},
+	{Name: "中秋节", EnglishName: "Mid-Autumn Festival", Month: 8, Day: 15, DayOff: true},
+	{Name: "重阳节", EnglishName: "Double Ninth Festival", Month: 9, Day: 9},
+	{Name: "腊八节", EnglishName: "Laba Festival", Month: 12, Day: 8},
+	{Name: "小年", EnglishName: "Little New Year", Month: 12, Day: 23},
+	// Ranks below Spring Festival, whose window covers it as 春节-1
+	{Name: "除夕", EnglishName: "New Year's Eve", Month: 12, Day: 0, Priority: -1},
+}
+
+// DisplayName returns a festival or festival day name followed by its English name,
+// e.g. "春节+2 (Spring Festival+2)", or the name unchanged if it is not a known festival
+func DisplayName(name string) string {
+	festival, offset := name, ""
+	if i := strings.LastIndexAny(name, "+-"); i > 0 {
+		festival, offset = name[:i], name[i:]
 	}
+	for _, f := range traditionalFestivals {
+		if f.Name == festival {
+			return fmt.Sprintf("%s (%s%s)", name, f.EnglishName, offset)
+		}
+	}
+	for _, term := range SolarTerms {
+		if term.Name == festival {
+			return fmt.Sprintf("%s (%s%s)", name, term.EnglishName, offset)
+		}
+	}
+	return name
 }
 
-// GetFestival returns the festival name if the given date is a lunar festival
+// GetFestival returns the festival name if the given date is a lunar festival, the
+// highest priority one if there are several
 func (c *LunarCalendar) GetFestival(date time.Time) (string, bool) {
-	for festival, festivalDate := range c.festivalMap {
-		if date.Year() == festivalDate.Year() &&
-			date.Month() == festivalDate.Month() &&
-			date.Day() == festivalDate.Day() {
-			return festival, true
+	return festivalOn(c.GetFestivalDays(date))
+}
+
+// GetFestivalDate returns the date of a festival in the given lunar year, at midnight in loc
+func (c *LunarCalendar) GetFestivalDate(festival string, year int, loc *time.Location) (time.Time, error) {
+	f, exists := c.festival(festival)
+	if !exists {
+		return time.Time{}, fmt.Errorf("festival %s not found", festival)
+	}
+	switch {
+	case f.SolarTerm != "":
+		if year < MinLunarYear || year > MaxLunarYear {
+			return time.Time{}, fmt.Errorf("lunar year %d out of range %d-%d", year, MinLunarYear, MaxLunarYear)
 		}
+		// Solar terms of a lunar year fall in the Gregorian year of the same number,
+		// except for the Minor and Major Cold before the lunar new year
+		return SolarTermDate(year, f.SolarTerm, loc)
+	case f.Day == 0:
+		// The last day of a month is the day before the first day of the next one
+		next := LunarDate{Year: year, Month: f.Month + 1, Day: 1}
+		if f.Month == 12 {
+			next = LunarDate{Year: year + 1, Month: 1, Day: 1}
+		}
+		date, err := FromLunar(next, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return date.AddDate(0, 0, -1), nil
+	default:
+		return FromLunar(LunarDate{Year: year, Month: f.Month, Day: f.Day}, loc)
 	}
-	return "", false
 }
 
-// GetPreviousFestivalDate returns the date of the same festival from the previous year
+// GetPreviousFestivalDate returns the most recent occurrence of a festival before currentDate
 func (c *LunarCalendar) GetPreviousFestivalDate(currentDate time.Time, festival string) (time.Time, error) {
-	festivalDate, exists := c.festivalMap[festival]
-	if !exists {
-		return time.Time{}, fmt.Errorf("festival %s not found", festival)
+	occurrences, err := c.GetFestivalOccurrences(currentDate, festival, 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return occurrences[0], nil
+}
+
+// GetFestivalOccurrences returns the n most recent occurrences of a festival before
+// currentDate, most recent first
+func (c *LunarCalendar) GetFestivalOccurrences(currentDate time.Time, festival string, n int) ([]time.Time, error) {
+	if _, exists := c.festival(festival); !exists {
+		return nil, fmt.Errorf("festival %s not found", festival)
+	}
+	lunar, err := ToLunar(currentDate)
+	if err != nil {
+		return nil, err
 	}
+	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
 
-	// Simply subtract one year from the festival date
-	return festivalDate.AddDate(-1, 0, 0), nil
+	var occurrences []time.Time
+	for year := lunar.Year; year >= MinLunarYear && len(occurrences) < n; year-- {
+		festivalDate, err := c.GetFestivalDate(festival, year, currentDate.Location())
+		if err != nil {
+			return nil, err
+		}
+		if festivalDate.Before(today) {
+			occurrences = append(occurrences, festivalDate)
+		}
+	}
+	if len(occurrences) < n {
+		return nil, fmt.Errorf("only %d occurrences of %s before %s", len(occurrences), festival, currentDate.Format("2006-01-02"))
+	}
+	return occurrences, nil
 }
 
 // GetNextFestival returns the next upcoming festival and its date
 func (c *LunarCalendar) GetNextFestival(currentDate time.Time) (string, time.Time, error) {
+	lunar, err := ToLunar(currentDate)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+
 	var nextFestival string
 	var nextDate time.Time
-	minDiff := time.Duration(1<<63 - 1)
-
-	for festival, festivalDate := range c.festivalMap {
-		// If the festival date is in the past, get next year's date
-		if festivalDate.Before(currentDate) {
-			festivalDate = festivalDate.AddDate(1, 0, 0)
-		}
-
-		diff := festivalDate.Sub(currentDate)
-		if diff < minDiff {
-			minDiff = diff
-			nextFestival = festival
-			nextDate = festivalDate
+	for _, festival := range c.festivals {
+		// If the festival is already past this lunar year, take next year's date
+		for _, year := range []int{lunar.Year, lunar.Year + 1} {
+			festivalDate, err := c.GetFestivalDate(festival.Name, year, currentDate.Location())
+			if err != nil || festivalDate.Before(today) {
+				continue
+			}
+			if nextFestival == "" || festivalDate.Before(nextDate) {
+				nextFestival = festival.Name
+				nextDate = festivalDate
+			}
+			break
 		}
 	}
 
@@ -69,5 +171,52 @@ func (c *LunarCalendar) GetNextFestival(currentDate time.Time) (string, time.Tim
 		return "", time.Time{}, fmt.Errorf("no upcoming festivals found")
 	}
 
+	// Of festivals on the same day, the highest priority one is next
+	nextFestival, _ = c.GetFestival(nextDate)
 	return nextFestival, nextDate, nil
 }
+
+// Festivals returns the names of the calendar's festivals
+func (c *LunarCalendar) Festivals() []string {
+	names := make([]string, len(c.festivals))
+	for i, f := range c.festivals {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// festival looks up a festival by name
+func (c *LunarCalendar) festival(name string) (LunarFestival, bool) {
+	for _, f := range c.festivals {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return LunarFestival{}, false
+}
+
+// SetFestivalPriority sets the priority of a festival over overlapping ones
+func (c *LunarCalendar) SetFestivalPriority(festival string, priority int) error {
+	for i := range c.festivals {
+		if c.festivals[i].Name == festival {
+			c.festivals[i].Priority = priority
+			return nil
+		}
+	}
+	return fmt.Errorf("festival %s not found", festival)
+}
+
+// SetFestivalWindow sets the days before and after a festival's day that belong to it
+func (c *LunarCalendar) SetFestivalWindow(festival string, before, after int) error {
+	if before < 0 || after < 0 {
+		return fmt.Errorf("invalid window of %s: days before and after must not be negative", festival)
+	}
+	for i := range c.festivals {
+		if c.festivals[i].Name == festival {
+			c.festivals[i].WindowBefore = before
+			c.festivals[i].WindowAfter = after
+			return nil
+		}
+	}
+	return fmt.Errorf("festival %s not found", festival)
+}
@@ -0,0 +1,102 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFestival(t *testing.T) {
+	c := NewLunarCalendar()
+	tests := []struct {
+		date     time.Time
+		festival string
+	}{
+		{time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local), "春节"},
+		{time.Date(2025, 1, 29, 0, 0, 0, 0, time.Local), "春节"},
+		{time.Date(2026, 2, 17, 0, 0, 0, 0, time.Local), "春节"},
+		{time.Date(2025, 2, 12, 0, 0, 0, 0, time.Local), "元宵节"},
+		{time.Date(2025, 5, 31, 0, 0, 0, 0, time.Local), "端午节"},
+		{time.Date(2025, 10, 6, 0, 0, 0, 0, time.Local), "中秋节"},
+		{time.Date(2025, 10, 7, 0, 0, 0, 0, time.Local), ""},
+		{time.Date(2024, 4, 4, 0, 0, 0, 0, time.Local), "清明节"},
+		{time.Date(2025, 4, 4, 0, 0, 0, 0, time.Local), "清明节"},
+		{time.Date(2026, 4, 5, 0, 0, 0, 0, time.Local), "清明节"},
+		{time.Date(2025, 8, 29, 0, 0, 0, 0, time.Local), "七夕"},
+		{time.Date(2025, 10, 29, 0, 0, 0, 0, time.Local), "重阳节"},
+		{time.Date(2026, 1, 26, 0, 0, 0, 0, time.Local), "腊八节"},
+		{time.Date(2026, 2, 10, 0, 0, 0, 0, time.Local), "小年"},
+		// New Year's Eve is the 30th of a 30-day twelfth month and the 29th of a 29-day one
+		{time.Date(2024, 2, 9, 0, 0, 0, 0, time.Local), "除夕"},
+		{time.Date(2025, 1, 28, 0, 0, 0, 0, time.Local), "除夕"},
+		{time.Date(2026, 2, 16, 0, 0, 0, 0, time.Local), "除夕"},
+	}
+
+	for _, tt := range tests {
+		festival, ok := c.GetFestival(tt.date)
+		assert.Equal(t, tt.festival, festival, tt.date.Format("2006-01-02"))
+		assert.Equal(t, tt.festival != "", ok)
+	}
+
+	date, err := c.GetFestivalDate("中秋节", 2026, time.Local)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 9, 25, 0, 0, 0, 0, time.Local), date)
+	_, err = c.GetFestivalDate("unknown", 2026, time.Local)
+	assert.Error(t, err)
+}
+
+func TestDisplayName(t *testing.T) {
+	assert.Equal(t, "春节 (Spring Festival)", DisplayName("春节"))
+	assert.Equal(t, "春节+2 (Spring Festival+2)", DisplayName("春节+2"))
+	assert.Equal(t, "除夕 (New Year's Eve)", DisplayName("除夕"))
+	assert.Equal(t, "冬至 (Winter Solstice)", DisplayName("冬至"))
+	assert.Equal(t, "Diwali", DisplayName("Diwali"))
+}
+
+func TestGetNextFestival(t *testing.T) {
+	c := NewLunarCalendar()
+	festival, date, err := c.GetNextFestival(time.Date(2025, 10, 7, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "重阳节", festival)
+	assert.Equal(t, time.Date(2025, 10, 29, 0, 0, 0, 0, time.Local), date)
+
+	festival, date, err = c.GetNextFestival(time.Date(2025, 10, 6, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "中秋节", festival)
+	assert.Equal(t, time.Date(2025, 10, 6, 0, 0, 0, 0, time.Local), date)
+}
+
+func TestGetPreviousFestivalDate(t *testing.T) {
+	c := NewLunarCalendar()
+	tests := []struct {
+		name    string
+		current time.Time
+		want    time.Time
+	}{
+		{"on the festival", time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local), time.Date(2023, 1, 22, 0, 0, 0, 0, time.Local)},
+		{"later in the same year", time.Date(2024, 6, 1, 12, 0, 0, 0, time.Local), time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local)},
+		{"before the festival", time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 1, 29, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := c.GetPreviousFestivalDate(tt.current, "春节")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, date)
+		})
+	}
+
+	occurrences, err := c.GetFestivalOccurrences(time.Date(2026, 9, 25, 0, 0, 0, 0, time.Local), "中秋节", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 10, 6, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 9, 17, 0, 0, 0, 0, time.Local),
+		time.Date(2023, 9, 29, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+
+	_, err = c.GetFestivalOccurrences(time.Date(1901, 1, 1, 0, 0, 0, 0, time.Local), "中秋节", 2)
+	assert.Error(t, err)
+	_, err = c.GetPreviousFestivalDate(time.Date(2024, 2, 10, 0, 0, 0, 0, time.Local), "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// Range of lunar years supported by the conversion
+const (
+	MinLunarYear = 1900
+	MaxLunarYear = 2100
+)
+
+// lunarInfo encodes one lunar year per entry from 1900 to 2100. Bits 15 to 4 are
+// set for the months 1 to 12 with 30 days (29 otherwise), bits 3 to 0 give the
+// leap month (0 for none) and bit 16 is set if the leap month has 30 days.
+var lunarInfo = [...]uint32{
+	0x04bd8, 0x04ae0, 0x0a570, 0x054d5, 0x0d260, 0x0d950, 0x16554, 0x056a0, 0x09ad0, 0x055d2, // 1900-1909
+	0x04ae0, 0x0a5b6, 0x0a4d0, 0x0d250, 0x1d255, 0x0b540, 0x0d6a0, 0x0ada2, 0x095b0, 0x14977, // 1910-1919
+	0x04970, 0x0a4b0, 0x0b4b5, 0x06a50, 0x06d40, 0x1ab54, 0x02b60, 0x09570, 0x052f2, 0x04970, // 1920-1929
+	0x06566, 0x0d4a0, 0x0ea50, 0x16a95, 0x05ad0, 0x02b60, 0x186e3, 0x092e0, 0x1c8d7, 0x0c950, // 1930-1939
+	0x0d4a0, 0x1d8a6, 0x0b550, 0x056a0, 0x1a5b4, 0x025d0, 0x092d0, 0x0d2b2, 0x0a950, 0x0b557, // 1940-1949
+	0x06ca0, 0x0b550, 0x15355, 0x04da0, 0x0a5b0, 0x14573, 0x052b0, 0x0a9a8, 0x0e950, 0x06aa0, // 1950-1959
+	0x0aea6, 0x0ab50, 0x04b60, 0x0aae4, 0x0a570, 0x05260, 0x0f263, 0x0d950, 0x05b57, 0x056a0, // 1960-1969
+	0x096d0, 0x04dd5, 0x04ad0, 0x0a4d0, 0x0d4d4, 0x0d250, 0x0d558, 0x0b540, 0x0b6a0, 0x195a6, // 1970-1979
+	0x095b0, 0x049b0, 0x0a974, 0x0a4b0, 0x0b27a, 0x06a50, 0x06d40, 0x0af46, 0x0ab60, 0x09570, // 1980-1989
+	0x04af5, 0x04970, 0x064b0, 0x074a3, 0x0ea50, 0x06b58, 0x05ac0, 0x0ab60, 0x096d5, 0x092e0, // 1990-1999
+	0x0c960, 0x0d954, 0x0d4a0, 0x0da50, 0x07552, 0x056a0, 0x0abb7, 0x025d0, 0x092d0, 0x0cab5, // 2000-2009
+	0x0a950, 0x0b4a0, 0x0baa4, 0x0ad50, 0x055d9, 0x04ba0, 0x0a5b0, 0x15176, 0x052b0, 0x0a930, // 2010-2019
+	0x07954, 0x06aa0, 0x0ad50, 0x05b52, 0x04b60, 0x0a6e6, 0x0a4e0, 0x0d260, 0x0ea65, 0x0d530, // 2020-2029
+	0x05aa0, 0x076a3, 0x096d0, 0x04afb, 0x04ad0, 0x0a4d0, 0x1d0b6, 0x0d250, 0x0d520, 0x0dd45, // 2030-2039
+	0x0b5a0, 0x056d0, 0x055b2, 0x049b0, 0x0a577, 0x0a4b0, 0x0aa50, 0x1b255, 0x06d20, 0x0ada0, // 2040-2049
+	0x14b63, 0x09370, 0x049f8, 0x04970, 0x064b0, 0x168a6, 0x0ea50, 0x06b20, 0x1a6c4, 0x0aae0, // 2050-2059
+	0x092e0, 0x0d2e3, 0x0c960, 0x0d557, 0x0d4a0, 0x0da50, 0x05d55, 0x056a0, 0x0a6d0, 0x055d4, // 2060-2069
+	0x052d0, 0x0a9b8, 0x0a950, 0x0b4a0, 0x0b6a6, 0x0ad50, 0x055a0, 0x0aba4, 0x0a5b0, 0x052b0, // 2070-2079
+	0x0b273, 0x06930, 0x07337, 0x06aa0, 0x0ad50, 0x14b55, 0x04b60, 0x0a570, 0x054e4, 0x0d160, // 2080-2089
+	0x0e968, 0x0d520, 0x0daa0, 0x16aa6, 0x056d0, 0x04ae0, 0x0a9d4, 0x0a2d0, 0x0d150, 0x0f252, // 2090-2099
+	0x0d520, // 2100
+}
+
+// lunarEpoch is the Gregorian date of the first day of lunar year 1900
+var lunarEpoch = time.Date(1900, 1, 31, 0, 0, 0, 0, time.UTC)
+
+// LunarDate is a date in the Chinese lunisolar calendar
+type LunarDate struct {
+	Year  int
+	Month int  // 1 to 12
+	Day   int  // 1 to 30
+	Leap  bool // the date is in the leap month following Month
+}
+
+// String returns the date as "2024-01-15", with "闰" before the month of a leap month
+func (d LunarDate) String() string {
+	if d.Leap {
+		return fmt.Sprintf("%04d-闰%02d-%02d", d.Year, d.Month, d.Day)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// ToLunar converts the calendar day of date, in its own location, to a lunar date
+func ToLunar(date time.Time) (LunarDate, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	offset := int(day.Sub(lunarEpoch).Hours() / 24)
+	if offset < 0 {
+		return LunarDate{}, fmt.Errorf("date %s is before lunar year %d", date.Format("2006-01-02"), MinLunarYear)
+	}
+
+	year := MinLunarYear
+	for ; year <= MaxLunarYear && offset >= lunarYearDays(year); year++ {
+		offset -= lunarYearDays(year)
+	}
+	if year > MaxLunarYear {
+		return LunarDate{}, fmt.Errorf("date %s is after lunar year %d", date.Format("2006-01-02"), MaxLunarYear)
+	}
+
+	leap := LeapMonth(year)
+	for month := 1; month <= 12; month++ {
+		if days := LunarMonthDays(year, month, false); offset >= days {
+			offset -= days
+		} else {
+			return LunarDate{Year: year, Month: month, Day: offset + 1}, nil
+		}
+		if month == leap {
+			if days := LunarMonthDays(year, month, true); offset >= days {
+				offset -= days
+			} else {
+				return LunarDate{Year: year, Month: month, Day: offset + 1, Leap: true}, nil
+			}
+		}
+	}
+	return LunarDate{}, fmt.Errorf("failed to convert %s to a lunar date", date.Format("2006-01-02"))
+}
+
+// FromLunar returns midnight in loc of the Gregorian day of a lunar date
+func FromLunar(d LunarDate, loc *time.Location) (time.Time, error) {
+	if d.Year < MinLunarYear || d.Year > MaxLunarYear {
+		return time.Time{}, fmt.Errorf("lunar year %d out of range %d-%d", d.Year, MinLunarYear, MaxLunarYear)
+	}
+	if d.Month < 1 || d.Month > 12 {
+		return time.Time{}, fmt.Errorf("invalid lunar month %d", d.Month)
+	}
+	if d.Leap && LeapMonth(d.Year) != d.Month {
+		return time.Time{}, fmt.Errorf("lunar year %d has no leap month %d", d.Year, d.Month)
+	}
+	if days := LunarMonthDays(d.Year, d.Month, d.Leap); d.Day < 1 || d.Day > days {
+		return time.Time{}, fmt.Errorf("invalid day %d of lunar month %s", d.Day, LunarDate{Year: d.Year, Month: d.Month, Leap: d.Leap})
+	}
+
+	offset := 0
+	for year := MinLunarYear; year < d.Year; year++ {
+		offset += lunarYearDays(year)
+	}
+	leap := LeapMonth(d.Year)
+	for month := 1; month < d.Month; month++ {
+		offset += LunarMonthDays(d.Year, month, false)
+		if month == leap {
+			offset += LunarMonthDays(d.Year, month, true)
+		}
+	}
+	if d.Leap {
+		offset += LunarMonthDays(d.Year, d.Month, false)
+	}
+	offset += d.Day - 1
+
+	day := lunarEpoch.AddDate(0, 0, offset)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// LeapMonth returns the month a lunar year's leap month follows, or 0 if it has none
+func LeapMonth(year int) int {
+	return int(lunarInfo[year-MinLunarYear] & 0xf)
+}
+
+// LunarMonthDays returns the number of days of a lunar month, or of its leap month
+func LunarMonthDays(year, month int, leap bool) int {
+	info := lunarInfo[year-MinLunarYear]
+	if leap {
+		if LeapMonth(year) != month {
+			return 0
+		}
+		if info&0x10000 != 0 {
+			return 30
+		}
+		return 29
+	}
+	if info&(0x10000>>month) != 0 {
+		return 30
+	}
+	return 29
+}
+
+// lunarYearDays returns the number of days of a lunar year
+func lunarYearDays(year int) int {
+	days := 0
+	for month := 1; month <= 12; month++ {
+		days += LunarMonthDays(year, month, false)
+	}
+	if leap := LeapMonth(year); leap != 0 {
+		days += LunarMonthDays(year, leap, true)
+	}
+	return days
+}
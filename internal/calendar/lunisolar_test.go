@@ -0,0 +1,81 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLunar(t *testing.T) {
+	tests := []struct {
+		date string
+		want LunarDate
+	}{
+		{"1900-01-31", LunarDate{Year: 1900, Month: 1, Day: 1}},
+		{"2000-02-05", LunarDate{Year: 2000, Month: 1, Day: 1}},
+		{"2023-01-22", LunarDate{Year: 2023, Month: 1, Day: 1}},
+		{"2024-09-17", LunarDate{Year: 2024, Month: 8, Day: 15}},
+		{"2025-01-29", LunarDate{Year: 2025, Month: 1, Day: 1}},
+		{"2026-02-17", LunarDate{Year: 2026, Month: 1, Day: 1}},
+		{"2008-08-08", LunarDate{Year: 2008, Month: 7, Day: 8}},
+		{"1976-01-01", LunarDate{Year: 1975, Month: 12, Day: 1}},
+		// Leap months
+		{"2020-05-23", LunarDate{Year: 2020, Month: 4, Day: 1, Leap: true}},
+		{"2023-03-22", LunarDate{Year: 2023, Month: 2, Day: 1, Leap: true}},
+		{"2033-12-22", LunarDate{Year: 2033, Month: 11, Day: 1, Leap: true}},
+		{"2100-12-31", LunarDate{Year: 2100, Month: 12, Day: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, err := time.ParseInLocation("2006-01-02", tt.date, time.Local)
+			assert.NoError(t, err)
+
+			lunar, err := ToLunar(date)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, lunar)
+
+			gregorian, err := FromLunar(lunar, time.Local)
+			assert.NoError(t, err)
+			assert.Equal(t, date, gregorian)
+		})
+	}
+
+	_, err := ToLunar(time.Date(1900, 1, 30, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+	_, err = ToLunar(time.Date(2101, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.Error(t, err)
+}
+
+func TestLunarRoundTrip(t *testing.T) {
+	previous := LunarDate{Year: 1899, Month: 12, Day: 30}
+	for date := lunarEpoch; date.Year() <= 2100; date = date.AddDate(0, 0, 1) {
+		lunar, err := ToLunar(date)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// Consecutive days advance the lunar day or start a new month
+		if lunar.Day != 1 {
+			assert.Equal(t, previous.Day+1, lunar.Day, date.Format("2006-01-02"))
+		}
+		previous = lunar
+
+		gregorian, err := FromLunar(lunar, time.UTC)
+		if !assert.NoError(t, err) || !assert.Equal(t, date, gregorian) {
+			return
+		}
+	}
+}
+
+func TestFromLunarInvalid(t *testing.T) {
+	for _, d := range []LunarDate{
+		{Year: 1899, Month: 1, Day: 1},
+		{Year: 2024, Month: 13, Day: 1},
+		{Year: 2024, Month: 4, Day: 1, Leap: true},
+		{Year: 2024, Month: 1, Day: 31},
+	} {
+		_, err := FromLunar(d, time.UTC)
+		assert.Error(t, err, d.String())
+	}
+}
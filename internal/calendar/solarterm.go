@@ -0,0 +1,124 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// SolarTerm is one of the 24 solar terms, reached when the apparent ecliptic longitude
+// of the sun is a multiple of 15 degrees
+type SolarTerm struct {
+	Name        string
+	EnglishName string
+	Longitude   float64 // degrees
+}
+
+// SolarTerms lists the solar terms in the order they occur in a Gregorian year
+var SolarTerms = [24]SolarTerm{
+	{"小寒", "Minor Cold", 285},
+	{"大寒", "Major Cold", 300},
+	{"立春", "Start of Spring", 315},
+	{"雨水", "Rain Water", 330},
+	{"惊蛰", "Awakening of Insects", 345},
+	{"春分", "Spring Equinox", 0},
+	{"清明", "Pure Brightness", 15},
+	{"谷雨", "Grain Rain", 30},
+	{"立夏", "Start of Summer", 45},
+	{"小满", "Grain Buds", 60},
+	{"芒种", "Grain in Ear", 75},
+	{"夏至", "Summer Solstice", 90},
+	{"小暑", "Minor Heat", 105},
+	{"大暑", "Major Heat", 120},
+	{"立秋", "Start of Autumn", 135},
+	{"处暑", "End of Heat", 150},
+	{"白露", "White Dew", 165},
+	{"秋分", "Autumn Equinox", 180},
+	{"寒露", "Cold Dew", 195},
+	{"霜降", "Frost's Descent", 210},
+	{"立冬", "Start of Winter", 225},
+	{"小雪", "Minor Snow", 240},
+	{"大雪", "Major Snow", 255},
+	{"冬至", "Winter Solstice", 270},
+}
+
+// chinaStandardTime is the time zone solar term dates are defined in
+var chinaStandardTime = time.FixedZone("CST", 8*3600)
+
+// SolarTermTime returns the instant the sun reaches the longitude of the named solar
+// term in the given Gregorian year, accurate to within about a quarter of an hour
+func SolarTermTime(year int, name string) (time.Time, error) {
+	index := -1
+	for i, term := range SolarTerms {
+		if term.Name == name {
+			index = i
+		}
+	}
+	if index < 0 {
+		return time.Time{}, fmt.Errorf("solar term %s not found", name)
+	}
+
+	// Terms are about 15.2 days apart starting with Minor Cold around January 6
+	jd := julianDay(time.Date(year, 1, 6, 0, 0, 0, 0, time.UTC)) + float64(index)*365.2422/24
+	for i := 0; i < 10; i++ {
+		delta := math.Mod(SolarTerms[index].Longitude-solarLongitude(jd)+540, 360) - 180
+		jd += delta / 360 * 365.2422
+		if math.Abs(delta) < 1e-7 {
+			break
+		}
+	}
+	return fromJulianDay(jd), nil
+}
+
+// SolarTermDate returns the day of a solar term in China Standard Time, at midnight in loc
+func SolarTermDate(year int, name string, loc *time.Location) (time.Time, error) {
+	instant, err := SolarTermTime(year, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	day := instant.In(chinaStandardTime)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// GetSolarTerm returns the solar term that falls on the calendar day of date
+func GetSolarTerm(date time.Time) (SolarTerm, bool) {
+	// Each month holds two terms, the first of them listed at index 2*(month-1)
+	for _, index := range []int{2 * (int(date.Month()) - 1), 2*(int(date.Month())-1) + 1} {
+		termDate, err := SolarTermDate(date.Year(), SolarTerms[index].Name, date.Location())
+		if err == nil && daysBetween(termDate, date) == 0 {
+			return SolarTerms[index], true
+		}
+	}
+	return SolarTerm{}, false
+}
+
+// solarLongitude returns the apparent ecliptic longitude of the sun in degrees at a
+// Julian day, using the low-accuracy solar coordinates of Meeus, Astronomical
+// Algorithms, chapter 25. The difference between terrestrial and universal time is
+// about a minute and ignored.
+func solarLongitude(jd float64) float64 {
+	t := (jd - 2451545.0) / 36525
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := radians(357.52911 + 35999.05029*t - 0.0001537*t*t)
+	c := (1.914602-0.004817*t-0.000014*t*t)*math.Sin(m) +
+		(0.019993-0.000101*t)*math.Sin(2*m) +
+		0.000289*math.Sin(3*m)
+	omega := radians(125.04 - 1934.136*t)
+	longitude := l0 + c - 0.00569 - 0.00478*math.Sin(omega)
+	return math.Mod(math.Mod(longitude, 360)+360, 360)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// julianDayUnixEpoch is the Julian day of 1970-01-01 00:00 UTC
+const julianDayUnixEpoch = 2440587.5
+
+func julianDay(t time.Time) float64 {
+	return julianDayUnixEpoch + float64(t.Unix())/86400
+}
+
+func fromJulianDay(jd float64) time.Time {
+	return time.Unix(int64(math.Round((jd-julianDayUnixEpoch)*86400)), 0).UTC()
+}
@@ -0,0 +1,42 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolarTermDate(t *testing.T) {
+	// Published dates of the 2024 solar terms in China Standard Time
+	want := []string{
+		"01-06", "01-20", "02-04", "02-19", "03-05", "03-20", "04-04", "04-19", "05-05", "05-20", "06-05", "06-21",
+		"07-06", "07-22", "08-07", "08-22", "09-07", "09-22", "10-08", "10-23", "11-07", "11-22", "12-06", "12-21",
+	}
+	for i, term := range SolarTerms {
+		date, err := SolarTermDate(2024, term.Name, time.Local)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-"+want[i], date.Format("2006-01-02"), term.Name)
+	}
+
+	// The Winter Solstice 2025 falls late in the evening in China, but on the next day in UTC
+	instant, err := SolarTermTime(2025, "冬至")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Date(2025, 12, 21, 15, 3, 0, 0, time.UTC).Unix(), instant.Unix(), 20*60)
+	date, err := SolarTermDate(2025, "冬至", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 21, 0, 0, 0, 0, time.UTC), date)
+
+	_, err = SolarTermDate(2024, "unknown", time.Local)
+	assert.Error(t, err)
+}
+
+func TestGetSolarTerm(t *testing.T) {
+	term, ok := GetSolarTerm(time.Date(2025, 4, 4, 12, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "清明", term.Name)
+	assert.Equal(t, "Pure Brightness", term.EnglishName)
+
+	_, ok = GetSolarTerm(time.Date(2025, 4, 5, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+}
@@ -0,0 +1,79 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// TableFestival is a festival whose date is listed for each Gregorian year, for
+// calendars that depend on local astronomical observation such as the Hindu calendar
+type TableFestival struct {
+	Name  string
+	Dates []time.Time
+	// The festival affects traffic from WindowBefore days before to WindowAfter days after its day
+	WindowBefore int
+	WindowAfter  int
+	Priority     int  // higher priorities win when festivals overlap
+	DayOff       bool // a public holiday, so its day and the days of its window after it are holidays
+}
+
+// diwaliDates lists Diwali (Lakshmi Puja) as observed in India
+var diwaliDates = []string{
+	"2015-11-11", "2016-10-30", "2017-10-19", "2018-11-07", "2019-10-27", "2020-11-14",
+	"2021-11-04", "2022-10-24", "2023-11-12", "2024-10-31", "2025-10-20", "2026-11-08",
+	"2027-10-29", "2028-10-17", "2029-11-05", "2030-10-26",
+}
+
+// Diwali with its five days from Dhanteras to Bhai Dooj; the days from Lakshmi Puja on are off
+var Diwali = TableFestival{Name: "Diwali", Dates: parseDates(diwaliDates), WindowBefore: 2, WindowAfter: 2, DayOff: true}
+
+// TableCalendar handles festivals with listed dates
+type TableCalendar struct {
+	yearlyCalendar
+}
+
+// NewHinduCalendar creates a calendar of Diwali
+func NewHinduCalendar() *TableCalendar {
+	return NewTableCalendar(Diwali)
+}
+
+// NewTableCalendar creates a calendar of the given festivals. Years without a listed
+// date have no occurrence of the festival.
+func NewTableCalendar(festivals ...TableFestival) *TableCalendar {
+	c := &TableCalendar{yearlyCalendar{yearOf: func(date time.Time) int { return date.Year() }}}
+	for _, festival := range festivals {
+		dates := make(map[int]time.Time)
+		for _, date := range festival.Dates {
+			dates[date.Year()] = utcDay(date)
+		}
+		name := festival.Name
+		c.festivals = append(c.festivals, yearlyFestival{
+			name: name,
+			date: func(year int) (time.Time, error) {
+				date, exists := dates[year]
+				if !exists {
+					return time.Time{}, fmt.Errorf("no date of %s listed for %d", name, year)
+				}
+				return date, nil
+			},
+			before:   festival.WindowBefore,
+			after:    festival.WindowAfter,
+			priority: festival.Priority,
+			dayOff:   festival.DayOff,
+		})
+	}
+	return c
+}
+
+// parseDates parses built-in dates in the format 2006-01-02
+func parseDates(values []string) []time.Time {
+	dates := make([]time.Time, len(values))
+	for i, value := range values {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in date %s: %v", value, err))
+		}
+		dates[i] = date
+	}
+	return dates
+}
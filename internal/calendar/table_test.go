@@ -0,0 +1,40 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableCalendar(t *testing.T) {
+	c := NewHinduCalendar()
+	day, ok := c.GetFestivalDay(time.Date(2025, 10, 18, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "Diwali-2", day.String())
+
+	occurrences, err := c.GetFestivalDayOccurrences(time.Date(2025, 10, 18, 0, 0, 0, 0, time.Local), day, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 10, 29, 0, 0, 0, 0, time.Local),
+		time.Date(2023, 11, 10, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+
+	// The days before Lakshmi Puja are not days off
+	assert.Equal(t, Workday, c.DayType(time.Date(2024, 10, 29, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Holiday, c.DayType(time.Date(2024, 10, 31, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, Holiday, c.DayType(time.Date(2024, 11, 1, 0, 0, 0, 0, time.Local)))
+
+	// Occurrences are limited to the listed years
+	_, err = c.GetFestivalOccurrences(time.Date(2016, 1, 1, 0, 0, 0, 0, time.Local), "Diwali", 2)
+	assert.Error(t, err)
+	_, ok = c.GetFestivalDay(time.Date(2040, 11, 1, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+
+	// Pluggable alongside the lunar calendar
+	combined := NewCombinedCalendar(NewLunarCalendar(), c, NewIslamicCalendar(0))
+	festival, date, err := combined.GetNextFestival(time.Date(2025, 10, 7, 0, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t, "Diwali", festival)
+	assert.Equal(t, time.Date(2025, 10, 20, 0, 0, 0, 0, time.Local), date)
+}
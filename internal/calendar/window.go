@@ -0,0 +1,143 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// FestivalDay locates a date within the window of a festival
+type FestivalDay struct {
+	Festival string
+	Offset   int // days after the festival's day, negative before it
+	Priority int // of the festival; higher priorities win when festivals overlap
+}
+
+// String returns the festival name, followed by the signed offset outside the festival's day, e.g. "春节+2"
+func (d FestivalDay) String() string {
+	if d.Offset == 0 {
+		return d.Festival
+	}
+	return fmt.Sprintf("%s%+d", d.Festival, d.Offset)
+}
+
+// GetFestivalDay returns the festival whose window contains date, the first of GetFestivalDays
+func (c *LunarCalendar) GetFestivalDay(date time.Time) (FestivalDay, bool) {
+	return firstFestivalDay(c.GetFestivalDays(date))
+}
+
+// GetFestivalDays returns all festivals whose window contains date, by descending
+// priority, then closest to their day, then in the order they are defined
+func (c *LunarCalendar) GetFestivalDays(date time.Time) []FestivalDay {
+	lunar, err := ToLunar(date)
+	if err != nil {
+		return nil
+	}
+
+	var occurrences []festivalOccurrence
+	for _, festival := range c.festivals {
+		// Windows can reach into the neighbouring lunar years
+		for year := lunar.Year - 1; year <= lunar.Year+1; year++ {
+			festivalDate, err := c.GetFestivalDate(festival.Name, year, date.Location())
+			if err != nil {
+				continue
+			}
+			occurrences = append(occurrences, festivalOccurrence{
+				festival: festival.Name,
+				date:     festivalDate,
+				before:   festival.WindowBefore,
+				after:    festival.WindowAfter,
+				priority: festival.Priority,
+			})
+		}
+	}
+	return festivalDays(date, occurrences)
+}
+
+// GetFestivalDayOccurrences returns the dates at the same offset from the n previous
+// occurrences of the festival, e.g. day 3 of the previous Spring Festivals for
+// "春节+2", most recent first
+func (c *LunarCalendar) GetFestivalDayOccurrences(currentDate time.Time, day FestivalDay, n int) ([]time.Time, error) {
+	return festivalDayOccurrences(c, currentDate, day, n)
+}
+
+// festivalOccurrence is the day of a festival in one year with its window
+type festivalOccurrence struct {
+	festival      string
+	date          time.Time
+	before, after int
+	priority      int
+}
+
+// festivalDays returns the festival days of date among the occurrences whose window
+// contains it, by descending priority, then closest to their day, then in the order of
+// the occurrences. A festival is returned once, for its best occurrence.
+func festivalDays(date time.Time, occurrences []festivalOccurrence) []FestivalDay {
+	var days []FestivalDay
+	for _, o := range occurrences {
+		offset := daysBetween(o.date, date)
+		if offset < -o.before || offset > o.after {
+			continue
+		}
+		days = append(days, FestivalDay{Festival: o.festival, Offset: offset, Priority: o.priority})
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		if days[i].Priority != days[j].Priority {
+			return days[i].Priority > days[j].Priority
+		}
+		return math.Abs(float64(days[i].Offset)) < math.Abs(float64(days[j].Offset))
+	})
+	return uniqueFestivalDays(days)
+}
+
+// uniqueFestivalDays keeps the first day of each festival
+func uniqueFestivalDays(days []FestivalDay) []FestivalDay {
+	var unique []FestivalDay
+	seen := make(map[string]bool)
+	for _, day := range days {
+		if !seen[day.Festival] {
+			seen[day.Festival] = true
+			unique = append(unique, day)
+		}
+	}
+	return unique
+}
+
+// firstFestivalDay returns the first of the festival days, if any
+func firstFestivalDay(days []FestivalDay) (FestivalDay, bool) {
+	if len(days) == 0 {
+		return FestivalDay{}, false
+	}
+	return days[0], true
+}
+
+// festivalOn returns the first of the festival days that is the festival's own day
+func festivalOn(days []FestivalDay) (string, bool) {
+	for _, day := range days {
+		if day.Offset == 0 {
+			return day.Festival, true
+		}
+	}
+	return "", false
+}
+
+// festivalDayOccurrences shifts the previous occurrences of the festival of day by its offset
+func festivalDayOccurrences(c Calendar, currentDate time.Time, day FestivalDay, n int) ([]time.Time, error) {
+	festivalDate := currentDate.AddDate(0, 0, -day.Offset)
+	occurrences, err := c.GetFestivalOccurrences(festivalDate, day.Festival, n)
+	if err != nil {
+		return nil, err
+	}
+	for i := range occurrences {
+		occurrences[i] = occurrences[i].AddDate(0, 0, day.Offset)
+	}
+	return occurrences, nil
+}
+
+// daysBetween returns the number of calendar days from a to b
+func daysBetween(a, b time.Time) int {
+	dayA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dayB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dayB.Sub(dayA).Hours() / 24)
+}
@@ -0,0 +1,62 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFestivalDay(t *testing.T) {
+	c := NewLunarCalendar()
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2026, 2, 16, 0, 0, 0, 0, time.Local), "春节-1"},
+		{time.Date(2026, 2, 17, 0, 0, 0, 0, time.Local), "春节"},
+		{time.Date(2026, 2, 19, 0, 0, 0, 0, time.Local), "春节+2"},
+		{time.Date(2026, 2, 23, 0, 0, 0, 0, time.Local), "春节+6"},
+		{time.Date(2026, 2, 24, 0, 0, 0, 0, time.Local), ""},
+		{time.Date(2026, 2, 15, 0, 0, 0, 0, time.Local), ""},
+		{time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local), "元宵节"},
+	}
+
+	for _, tt := range tests {
+		day, ok := c.GetFestivalDay(tt.date)
+		assert.Equal(t, tt.want != "", ok, tt.date.Format("2006-01-02"))
+		if ok {
+			assert.Equal(t, tt.want, day.String())
+		}
+	}
+
+	// A travel-rush window reaching back into the previous lunar year
+	assert.NoError(t, c.SetFestivalWindow("春节", 15, 6))
+	day, ok := c.GetFestivalDay(time.Date(2026, 2, 2, 0, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, FestivalDay{Festival: "春节", Offset: -15}, day)
+
+	// Overlapping windows resolve to the closest festival
+	assert.NoError(t, c.SetFestivalWindow("春节", 1, 20))
+	day, _ = c.GetFestivalDay(time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local))
+	assert.Equal(t, "元宵节", day.Festival)
+
+	assert.Error(t, c.SetFestivalWindow("unknown", 1, 1))
+	assert.Error(t, c.SetFestivalWindow("春节", -1, 1))
+}
+
+func TestGetFestivalDayOccurrences(t *testing.T) {
+	c := NewLunarCalendar()
+	// Day 3 of Spring Festival 2026 compares with day 3 of 2025 and 2024
+	occurrences, err := c.GetFestivalDayOccurrences(time.Date(2026, 2, 19, 0, 0, 0, 0, time.Local), FestivalDay{Festival: "春节", Offset: 2}, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local),
+		time.Date(2024, 2, 12, 0, 0, 0, 0, time.Local),
+	}, occurrences)
+
+	// New Year's Eve
+	occurrences, err = c.GetFestivalDayOccurrences(time.Date(2026, 2, 16, 0, 0, 0, 0, time.Local), FestivalDay{Festival: "春节", Offset: -1}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{time.Date(2025, 1, 28, 0, 0, 0, 0, time.Local)}, occurrences)
+}
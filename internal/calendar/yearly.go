@@ -0,0 +1,184 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// yearlyFestival is a festival falling once in each year of some calendar
+type yearlyFestival struct {
+	name string
+	// date returns the festival's day in a year of the calendar at midnight UTC, or an
+	// error if the year is out of the calendar's range
+	date          func(year int) (time.Time, error)
+	before, after int
+	priority      int
+	dayOff        bool
+}
+
+// yearlyCalendar implements Calendar for festivals that fall once a year of a calendar
+// whose years need not match Gregorian years
+type yearlyCalendar struct {
+	festivals []yearlyFestival
+	// yearOf returns the year of the calendar containing the calendar day of date
+	yearOf func(date time.Time) int
+}
+
+// GetFestival returns the festival on the day of date, the highest priority one if there are several
+func (c *yearlyCalendar) GetFestival(date time.Time) (string, bool) {
+	return festivalOn(c.GetFestivalDays(date))
+}
+
+// GetFestivalDate returns the date of a festival in the given year of the calendar, at midnight in loc
+func (c *yearlyCalendar) GetFestivalDate(festival string, year int, loc *time.Location) (time.Time, error) {
+	f, exists := c.festival(festival)
+	if !exists {
+		return time.Time{}, fmt.Errorf("festival %s not found", festival)
+	}
+	date, err := f.date(year)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// GetFestivalDay returns the festival whose window contains date, the first of GetFestivalDays
+func (c *yearlyCalendar) GetFestivalDay(date time.Time) (FestivalDay, bool) {
+	return firstFestivalDay(c.GetFestivalDays(date))
+}
+
+// GetFestivalDays returns all festivals whose window contains date, by descending
+// priority, then closest to their day, then in the order they are defined
+func (c *yearlyCalendar) GetFestivalDays(date time.Time) []FestivalDay {
+	var occurrences []festivalOccurrence
+	for _, festival := range c.festivals {
+		// Windows can reach into the neighbouring years
+		for year := c.yearOf(date) - 1; year <= c.yearOf(date)+1; year++ {
+			festivalDate, err := c.GetFestivalDate(festival.name, year, date.Location())
+			if err != nil {
+				continue
+			}
+			occurrences = append(occurrences, festivalOccurrence{
+				festival: festival.name,
+				date:     festivalDate,
+				before:   festival.before,
+				after:    festival.after,
+				priority: festival.priority,
+			})
+		}
+	}
+	return festivalDays(date, occurrences)
+}
+
+// GetFestivalOccurrences returns the n most recent occurrences of a festival before
+// currentDate, most recent first
+func (c *yearlyCalendar) GetFestivalOccurrences(currentDate time.Time, festival string, n int) ([]time.Time, error) {
+	if _, exists := c.festival(festival); !exists {
+		return nil, fmt.Errorf("festival %s not found", festival)
+	}
+	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+
+	var occurrences []time.Time
+	for year := c.yearOf(currentDate); len(occurrences) < n; year-- {
+		festivalDate, err := c.GetFestivalDate(festival, year, currentDate.Location())
+		if err != nil {
+			return nil, fmt.Errorf("only %d occurrences of %s before %s: %w", len(occurrences), festival, currentDate.Format("2006-01-02"), err)
+		}
+		if festivalDate.Before(today) {
+			occurrences = append(occurrences, festivalDate)
+		}
+	}
+	return occurrences, nil
+}
+
+// GetFestivalDayOccurrences returns the dates at the same offset from the n previous
+// occurrences of the festival, most recent first
+func (c *yearlyCalendar) GetFestivalDayOccurrences(currentDate time.Time, day FestivalDay, n int) ([]time.Time, error) {
+	return festivalDayOccurrences(c, currentDate, day, n)
+}
+
+// GetNextFestival returns the next upcoming festival and its date
+func (c *yearlyCalendar) GetNextFestival(currentDate time.Time) (string, time.Time, error) {
+	today := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+
+	var nextFestival string
+	var nextDate time.Time
+	for _, festival := range c.festivals {
+		// If the festival is already past this year, take next year's date
+		for _, year := range []int{c.yearOf(currentDate), c.yearOf(currentDate) + 1} {
+			festivalDate, err := c.GetFestivalDate(festival.name, year, currentDate.Location())
+			if err != nil || festivalDate.Before(today) {
+				continue
+			}
+			if nextFestival == "" || festivalDate.Before(nextDate) {
+				nextFestival = festival.name
+				nextDate = festivalDate
+			}
+			break
+		}
+	}
+
+	if nextFestival == "" {
+		return "", time.Time{}, fmt.Errorf("no upcoming festivals found")
+	}
+
+	// Of festivals on the same day, the highest priority one is next
+	nextFestival, _ = c.GetFestival(nextDate)
+	return nextFestival, nextDate, nil
+}
+
+// Festivals returns the names of the calendar's festivals
+func (c *yearlyCalendar) Festivals() []string {
+	names := make([]string, len(c.festivals))
+	for i, festival := range c.festivals {
+		names[i] = festival.name
+	}
+	return names
+}
+
+// DayType classifies date as a holiday from the day of a festival that is a day off to
+// the end of its window, otherwise as weekend or workday. Days before such a festival,
+// e.g. Christmas Eve, and other festivals, e.g. Black Friday or Ramadan, are not holidays.
+func (c *yearlyCalendar) DayType(date time.Time) DayType {
+	for _, day := range c.GetFestivalDays(date) {
+		if festival, _ := c.festival(day.Festival); festival.dayOff && day.Offset >= 0 {
+			return Holiday
+		}
+	}
+	return weekdayType(date)
+}
+
+// SetFestivalPriority sets the priority of a festival over overlapping ones
+func (c *yearlyCalendar) SetFestivalPriority(festival string, priority int) error {
+	for i := range c.festivals {
+		if c.festivals[i].name == festival {
+			c.festivals[i].priority = priority
+			return nil
+		}
+	}
+	return fmt.Errorf("festival %s not found", festival)
+}
+
+// festival looks up a festival by name
+func (c *yearlyCalendar) festival(name string) (yearlyFestival, bool) {
+	for _, festival := range c.festivals {
+		if festival.name == name {
+			return festival, true
+		}
+	}
+	return yearlyFestival{}, false
+}
+
+// rataDie returns the fixed day number of the calendar day of date, day 1 being
+// January 1 of year 1 of the proleptic Gregorian calendar
+func rataDie(date time.Time) int {
+	return rataDieUnixEpoch + int(utcDay(date).Unix()/86400)
+}
+
+// fromRataDie returns the day of a fixed day number at midnight UTC
+func fromRataDie(rd int) time.Time {
+	return time.Unix(int64(rd-rataDieUnixEpoch)*86400, 0).UTC()
+}
+
+// rataDieUnixEpoch is the fixed day number of 1970-01-01
+const rataDieUnixEpoch = 719163
@@ -0,0 +1,94 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/calendar"
+)
+
+// Baseline selects the historical date the current date is compared with
+type Baseline struct {
+	Period string // label of the comparison, e.g. "7 days ago"
+	Select func(currentDate time.Time, cal calendar.Calendar) (time.Time, error)
+}
+
+// DaysAgo compares with the date the given number of days earlier
+func DaysAgo(days int) Baseline {
+	period := fmt.Sprintf("%d days ago", days)
+	if days == 1 {
+		period = "1 day ago"
+	}
+	return Baseline{
+		Period: period,
+		Select: func(currentDate time.Time, _ calendar.Calendar) (time.Time, error) {
+			return currentDate.AddDate(0, 0, -days), nil
+		},
+	}
+}
+
+// YearAgo compares with the date 365 days earlier, which usually falls on a different weekday
+func YearAgo() Baseline {
+	baseline := DaysAgo(365)
+	baseline.Period = "1 year ago"
+	return baseline
+}
+
+// SameWeekdayWeeksAgo compares with the same weekday the given number of weeks earlier
+func SameWeekdayWeeksAgo(weeks int) Baseline {
+	period := fmt.Sprintf("same weekday %d weeks ago", weeks)
+	if weeks == 1 {
+		period = "same weekday 1 week ago"
+	}
+	return Baseline{
+		Period: period,
+		Select: func(currentDate time.Time, _ calendar.Calendar) (time.Time, error) {
+			return currentDate.AddDate(0, 0, -7*weeks), nil
+		},
+	}
+}
+
+// SameWeekdayYearAgo compares with the date 364 days (52 weeks) earlier, which falls on the same weekday
+func SameWeekdayYearAgo() Baseline {
+	baseline := SameWeekdayWeeksAgo(52)
+	baseline.Period = "1 year ago (same weekday)"
+	return baseline
+}
+
+// SameDayType compares with the most recent earlier date of the same day type
+// (workday, weekend or holiday), looking back at most maxDays days. Workdays and
+// make-up workdays count as the same type.
+func SameDayType(maxDays int) Baseline {
+	return Baseline{
+		Period: "previous day of the same type",
+		Select: func(currentDate time.Time, cal calendar.Calendar) (time.Time, error) {
+			dayType := cal.DayType(currentDate)
+			for days := 1; days <= maxDays; days++ {
+				date := currentDate.AddDate(0, 0, -days)
+				if candidate := cal.DayType(date); candidate == dayType || candidate.Working() && dayType.Working() {
+					return date, nil
+				}
+			}
+			return time.Time{}, fmt.Errorf("no %s found in the %d days before %s", dayType, maxDays, currentDate.Format("2006-01-02"))
+		},
+	}
+}
+
+// DefaultBaselines returns the comparisons with the previous day of the same type and
+// the same weekday 1 and 4 weeks and 1 year ago, so weekends and holidays are not
+// compared with workdays
+func DefaultBaselines() []Baseline {
+	return []Baseline{SameDayType(30), SameWeekdayWeeksAgo(1), SameWeekdayWeeksAgo(4), SameWeekdayYearAgo()}
+}
+
+// SetBaselines sets the regular baselines MonitorTraffic compares with, in addition
+// to the previous occurrence of a festival
+func (m *Monitor) SetBaselines(baselines ...Baseline) error {
+	for _, b := range baselines {
+		if b.Period == "" || b.Select == nil {
+			return fmt.Errorf("baseline period and selector are required")
+		}
+	}
+	m.baselines = baselines
+	return nil
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+)
+
+// DetectorConfig selects a registered detector and its parameters
+type DetectorConfig struct {
+	Name   string
+	Params analyzer.DetectorParams
+}
+
+// SetDetectors sets the detectors run for every series without its own configuration
+func (m *Monitor) SetDetectors(configs ...DetectorConfig) error {
+	if _, err := newDetectors(configs); err != nil {
+		return err
+	}
+	m.detectors = configs
+	return nil
+}
+
+// SetSeriesDetectors sets the detectors run for a single module and IDC
+func (m *Monitor) SetSeriesDetectors(module, idc string, configs ...DetectorConfig) error {
+	if _, err := newDetectors(configs); err != nil {
+		return err
+	}
+	m.seriesDetectors[seriesKey(module, idc)] = configs
+	return nil
+}
+
+// detectorsFor instantiates the detectors configured for a module and IDC
+func (m *Monitor) detectorsFor(module, idc string) ([]analyzer.Detector, error) {
+	if configs, exists := m.seriesDetectors[seriesKey(module, idc)]; exists {
+		return newDetectors(configs)
+	}
+	return newDetectors(m.detectors)
+}
+
+func newDetectors(configs []DetectorConfig) ([]analyzer.Detector, error) {
+	detectors := make([]analyzer.Detector, 0, len(configs))
+	for _, config := range configs {
+		detector, err := analyzer.NewDetector(config.Name, config.Params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create detector: %w", err)
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
+
+func seriesKey(module, idc string) string {
+	return module + "/" + idc
+}
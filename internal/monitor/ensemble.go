@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// EnsembleMode selects how MonitorTraffic combines its baselines
+type EnsembleMode int
+
+const (
+	// EnsembleNone sends one notification for every baseline that fired
+	EnsembleNone EnsembleMode = iota
+	// EnsembleMedian fires when the median increase over all baselines exceeds the monitor threshold
+	EnsembleMedian
+	// EnsembleWeightedVote fires when the baselines that fired carry at least Quorum of the total weight
+	EnsembleWeightedVote
+	// EnsembleKOfN fires when at least K baselines fired
+	EnsembleKOfN
+)
+
+// String returns the name of the ensemble mode
+func (e EnsembleMode) String() string {
+	switch e {
+	case EnsembleMedian:
+		return "median"
+	case EnsembleWeightedVote:
+		return "weighted-vote"
+	case EnsembleKOfN:
+		return "k-of-n"
+	default:
+		return "none"
+	}
+}
+
+// EnsembleConfig configures how the baselines are combined into a single verdict
+type EnsembleConfig struct {
+	Mode    EnsembleMode
+	K       int                // baselines that must fire with EnsembleKOfN
+	Quorum  float64            // share of the total weight that must fire with EnsembleWeightedVote, 0.5 = majority
+	Weights map[string]float64 // weight per baseline period, e.g. {"1 year ago": 0.5}; unlisted periods weigh 1
+}
+
+// SetEnsemble combines all baselines into one verdict and one notification per run
+func (m *Monitor) SetEnsemble(config EnsembleConfig) error {
+	switch config.Mode {
+	case EnsembleNone, EnsembleMedian:
+	case EnsembleWeightedVote:
+		if config.Quorum <= 0 || config.Quorum > 1 {
+			return fmt.Errorf("invalid ensemble quorum %v: must be in (0, 1]", config.Quorum)
+		}
+	case EnsembleKOfN:
+		if config.K < 1 {
+			return fmt.Errorf("invalid ensemble k %d: must be at least 1", config.K)
+		}
+	default:
+		return fmt.Errorf("unknown ensemble mode %d", config.Mode)
+	}
+	for period, weight := range config.Weights {
+		if weight < 0 {
+			return fmt.Errorf("invalid ensemble weight %v for %s: must not be negative", weight, period)
+		}
+	}
+	m.ensemble = config
+	return nil
+}
+
+// weight returns the ensemble weight of a baseline period
+func (c EnsembleConfig) weight(period string) float64 {
+	if weight, exists := c.Weights[period]; exists {
+		return weight
+	}
+	return 1
+}
+
+// verdict reports whether the baselines together indicate a significant change and
+// describes the rule that was applied
+func (m *Monitor) verdict(baselines []types.BaselineResult) (bool, string) {
+	fired, firedWeight, totalWeight := 0, 0.0, 0.0
+	increases := make([]float64, len(baselines))
+	for i, b := range baselines {
+		increases[i] = b.Increase
+		totalWeight += b.Weight
+		if b.Fired {
+			fired++
+			firedWeight += b.Weight
+		}
+	}
+
+	switch m.ensemble.Mode {
+	case EnsembleMedian:
+		return median(increases) > m.threshold, fmt.Sprintf("median increase of %d baselines", len(baselines))
+	case EnsembleWeightedVote:
+		share := 0.0
+		if totalWeight > 0 {
+			share = firedWeight / totalWeight
+		}
+		return fired > 0 && share >= m.ensemble.Quorum,
+			fmt.Sprintf("weighted vote %.0f%% of %d baselines (quorum %.0f%%)", share*100, len(baselines), m.ensemble.Quorum*100)
+	case EnsembleKOfN:
+		return fired >= m.ensemble.K, fmt.Sprintf("%d of %d baselines (k=%d)", fired, len(baselines), m.ensemble.K)
+	}
+	return false, ""
+}
+
+// ensembleNotification builds the single notification of an ensemble verdict. Increase and
+// historical mean are the medians over all baselines.
+func (m *Monitor) ensembleNotification(base types.Notification, baselines []types.BaselineResult, rule string) types.Notification {
+	increases := make([]float64, len(baselines))
+	means := make([]float64, len(baselines))
+	for i, b := range baselines {
+		increases[i] = b.Increase
+		means[i] = b.HistoricalMean
+	}
+
+	n := base
+	n.Period = fmt.Sprintf("Ensemble: %s", rule)
+	n.Increase = median(increases)
+	n.HistoricalMean = median(means)
+	n.Baselines = baselines
+	// Festival baselines come first, highest priority festival first
+	for _, b := range baselines {
+		if b.Festival != "" {
+			n.Festival = b.Festival
+			break
+		}
+	}
+	return n
+}
+
+// median returns the median of values, or 0 for none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
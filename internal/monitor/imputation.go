@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// SetImputation fills missing points of the current and historical data before detection,
+// e.g. analyzer.ImputationOptions{Method: analyzer.ImputeLinear, ZeroIsMissing: true}
+func (m *Monitor) SetImputation(opts analyzer.ImputationOptions) {
+	m.imputation = &opts
+}
+
+// impute returns an analyzer over the data of a module and IDC for date with missing
+// points imputed as configured, from midnight through the end of the day or, for today,
+// until now. The seasonal naive method reads the previous day.
+func (m *Monitor) impute(module, idc string, date time.Time, data []types.TrafficData) (*analyzer.TimeSeriesAnalyzer, error) {
+	a := analyzer.NewTimeSeriesAnalyzer(data)
+	if m.imputation == nil {
+		return a, nil
+	}
+
+	opts := *m.imputation
+	opts.Start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	opts.End = opts.Start.AddDate(0, 0, 1)
+	if now := time.Now(); now.Before(opts.End) {
+		opts.End = now
+	}
+	if opts.Method == analyzer.ImputeSeasonalNaive {
+		// Without the previous day the missing points are interpolated linearly
+		if previous, err := m.dataProvider.GetData(module, idc, date.AddDate(0, 0, -1)); err == nil {
+			opts.PreviousDay = previous
+		}
+	}
+	if err := a.Impute(opts); err != nil {
+		return nil, fmt.Errorf("failed to impute missing data: %w", err)
+	}
+	return a, nil
+}
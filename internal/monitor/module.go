@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+	"go.uber.org/zap"
+)
+
+// MonitorModule compares the traffic of all given IDCs of a module with each baseline
+// together, so that traffic moving from one IDC to another is reported as a single
+// migration instead of separate drop and surge alerts. It returns one notification per
+// baseline with a significant change, carrying the module total and per-IDC changes.
+func (m *Monitor) MonitorModule(module string, idcs []string, currentDate time.Time) ([]types.Notification, error) {
+	if len(idcs) == 0 {
+		return nil, fmt.Errorf("no IDCs given for module %s", module)
+	}
+
+	current, err := m.moduleData(module, idcs, currentDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoCurrentData, err)
+	}
+
+	cal := m.CalendarFor(idcs...)
+	comparisons, err := m.comparisons(currentDate, cal)
+	if err != nil {
+		return nil, err
+	}
+	festivals := festivalNames(cal.GetFestivalDays(currentDate))
+
+	var notifications []types.Notification
+	for _, c := range comparisons {
+		historical, err := m.moduleData(module, idcs, c.date)
+		if err != nil {
+			m.logger.Warn("Skipping baseline without data",
+				zap.String("period", c.period),
+				zap.Time("date", c.date),
+				zap.Error(err))
+			continue
+		}
+
+		shift, err := analyzer.AnalyzeTrafficShift(current, historical, m.threshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare IDCs with %s: %w", c.period, err)
+		}
+		if shift.Kind == types.ShiftNone {
+			continue
+		}
+
+		// Rank the IDCs that explain a change of the module total
+		var rootCauses []types.RootCause
+		if shift.Kind == types.ShiftGrowth || shift.Kind == types.ShiftDecline {
+			rootCauses, err = analyzer.LocalizeRootCause(labeledByIDC(current, historical), analyzer.DefaultRootCauseOptions())
+			if err != nil {
+				return nil, fmt.Errorf("failed to localize root cause: %w", err)
+			}
+		}
+
+		m.logger.Warn("Significant module traffic change detected",
+			zap.String("module", module),
+			zap.String("period", c.period),
+			zap.Stringer("kind", shift.Kind),
+			zap.Float64("increase", shift.Total.Change))
+
+		notifications = append(notifications, types.Notification{
+			Module:         module,
+			IDC:            strings.Join(idcs, ","),
+			CurrentDate:    currentDate,
+			HistoricalDate: c.date,
+			Period:         c.period,
+			Increase:       shift.Total.Change,
+			CurrentMean:    shift.Total.CurrentMean,
+			HistoricalMean: shift.Total.BaselineMean,
+			Festival:       c.festival,
+			Festivals:      festivals,
+			Shift:          &shift,
+			RootCauses:     rootCauses,
+		})
+	}
+
+	return notifications, nil
+}
+
+// RunModuleMonitoring runs the module-wide comparison for all given IDCs and sends the notifications
+func (m *Monitor) RunModuleMonitoring(module string, idcs []string, currentDate time.Time) error {
+	notifications, err := m.MonitorModule(module, idcs, currentDate)
+	if err != nil {
+		return fmt.Errorf("module monitoring failed: %w", err)
+	}
+
+	for _, notification := range notifications {
+		if err := m.notifier.Send(notification); err != nil {
+			m.logger.Error("Failed to send notification",
+				zap.Error(err),
+				zap.Any("notification", notification))
+		}
+	}
+
+	return nil
+}
+
+// moduleData loads the traffic of every IDC of a module for a date at the comparison resolution
+func (m *Monitor) moduleData(module string, idcs []string, date time.Time) (map[string][]types.TrafficData, error) {
+	data := make(map[string][]types.TrafficData, len(idcs))
+	for _, idc := range idcs {
+		series, err := m.dataProvider.GetData(module, idc, date)
+		if err != nil {
+			return nil, err
+		}
+		if data[idc], err = m.resample(series); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// labeledByIDC pairs the current and historical traffic of each IDC for root-cause localization
+func labeledByIDC(current, historical map[string][]types.TrafficData) []analyzer.LabeledSeries {
+	series := make([]analyzer.LabeledSeries, 0, len(current))
+	for idc, data := range current {
+		series = append(series, analyzer.LabeledSeries{
+			Labels:   map[string]string{"idc": idc},
+			Current:  data,
+			Baseline: historical[idc],
+		})
+	}
+	return series
+}
@@ -1,7 +1,9 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
@@ -33,24 +35,155 @@ type Notification struct {
 	Forecast       []float64
 }
 
+// ErrNoCurrentData is returned when the data of the monitored day cannot be read
+var ErrNoCurrentData = errors.New("failed to get current data")
+
 // Monitor handles traffic monitoring and anomaly detection
 type Monitor struct {
-	threshold    float64
-	logger       *zap.Logger
-	calendar     *calendar.LunarCalendar
-	dataProvider data.Provider
-	notifier     notification.Notifier
+	threshold          float64
+	logger             *zap.Logger
+	calendar           *calendar.LunarCalendar
+	idcCalendars       map[string][]calendar.Calendar
+	namedCalendars     map[string]calendar.Calendar
+	dataProvider       data.Provider
+	notifier           notification.Notifier
+	forecastResolution time.Duration
+	forecastSteps      int
+	detectors          []DetectorConfig
+	seriesDetectors    map[string][]DetectorConfig
+	ensemble           EnsembleConfig
+	baselines          []Baseline
+	imputation         *analyzer.ImputationOptions
+	resolution         *analyzer.ResampleOptions
+	festivalYears      int
 }
 
 // NewMonitor creates a new traffic monitor instance
 func NewMonitor(threshold float64, logger *zap.Logger) *Monitor {
 	return &Monitor{
-		threshold:    threshold,
-		logger:       logger,
-		calendar:     calendar.NewLunarCalendar(),
-		dataProvider: data.NewProvider(),
-		notifier:     notification.NewNotifier(),
+		threshold:          threshold,
+		logger:             logger,
+		calendar:           calendar.NewLunarCalendar(),
+		dataProvider:       data.NewProvider(),
+		notifier:           notification.NewNotifier(),
+		forecastResolution: time.Hour,
+		forecastSteps:      24,
+		detectors: []DetectorConfig{
+			{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": threshold}},
+			{Name: analyzer.DetectorSeasonalBand},
+		},
+		seriesDetectors: make(map[string][]DetectorConfig),
+		idcCalendars:    make(map[string][]calendar.Calendar),
+		namedCalendars:  make(map[string]calendar.Calendar),
+		baselines:       DefaultBaselines(),
+		festivalYears:   1,
+	}
+}
+
+// SetForecastHorizon configures the forecast attached to notifications as steps
+// intervals of the given resolution, e.g. (time.Minute, 1440) for the next day per minute
+func (m *Monitor) SetForecastHorizon(resolution time.Duration, steps int) {
+	m.forecastResolution = resolution
+	m.forecastSteps = steps
+}
+
+// SetFestivalYears sets how many previous occurrences of a festival a festival day is compared with
+func (m *Monitor) SetFestivalYears(years int) error {
+	if years < 1 {
+		return fmt.Errorf("invalid number of festival years %d", years)
+	}
+	m.festivalYears = years
+	return nil
+}
+
+// LoadHolidaySchedules loads the official holiday schedules of a country for the given
+// years from <dir>/<country>_<year>.csv, used to classify days for baseline selection
+func (m *Monitor) LoadHolidaySchedules(dir, country string, years ...int) error {
+	if err := m.calendar.LoadHolidaySchedules(dir, country, years...); err != nil {
+		return fmt.Errorf("failed to load holiday schedules: %w", err)
+	}
+	return nil
+}
+
+// SetIDCCalendars sets the calendars whose festivals and day types apply to an IDC, in
+// order of precedence. IDCs without calendars use the Chinese lunar calendar.
+func (m *Monitor) SetIDCCalendars(idc string, calendars ...calendar.Calendar) error {
+	if len(calendars) == 0 {
+		return fmt.Errorf("no calendars given for IDC %s", idc)
+	}
+	m.idcCalendars[idc] = calendars
+	return nil
+}
+
+// calendarsByName creates the regional calendars SetIDCCalendarNames accepts besides CN
+var calendarsByName = map[string]func() calendar.Calendar{
+	"US":      func() calendar.Calendar { return calendar.NewUSCalendar() },
+	"EU":      func() calendar.Calendar { return calendar.NewEuropeanCalendar() },
+	"ISLAMIC": func() calendar.Calendar { return calendar.NewIslamicCalendar(0) },
+	"HEBREW":  func() calendar.Calendar { return calendar.NewHebrewCalendar() },
+	"HINDU":   func() calendar.Calendar { return calendar.NewHinduCalendar() },
+}
+
+// SetIDCCalendarNames sets the calendars of an IDC by name, in order of precedence: CN
+// for the lunar calendar with its holiday schedules, US, EU, islamic, hebrew or hindu.
+// Names are case-insensitive and IDCs given the same name share one calendar.
+func (m *Monitor) SetIDCCalendarNames(idc string, names ...string) error {
+	calendars := make([]calendar.Calendar, len(names))
+	for i, name := range names {
+		key := strings.ToUpper(name)
+		if key == "CN" {
+			calendars[i] = m.calendar
+			continue
+		}
+		if _, exists := m.namedCalendars[key]; !exists {
+			newCalendar, known := calendarsByName[key]
+			if !known {
+				return fmt.Errorf("unknown calendar %s for IDC %s", name, idc)
+			}
+			m.namedCalendars[key] = newCalendar()
+		}
+		calendars[i] = m.namedCalendars[key]
+	}
+	return m.SetIDCCalendars(idc, calendars...)
+}
+
+// LoadEvents imports the events of an iCalendar file as festivals of the given IDCs,
+// taking precedence over their other calendars
+func (m *Monitor) LoadEvents(path string, idcs ...string) error {
+	events, err := calendar.LoadICS(path)
+	if err != nil {
+		return fmt.Errorf("failed to load events: %w", err)
+	}
+	for _, idc := range idcs {
+		calendars, exists := m.idcCalendars[idc]
+		if !exists {
+			calendars = []calendar.Calendar{m.calendar}
+		}
+		m.idcCalendars[idc] = append([]calendar.Calendar{events}, calendars...)
+	}
+	return nil
+}
+
+// CalendarFor returns the calendar the monitor uses for the given IDCs, combining their calendars if they differ
+func (m *Monitor) CalendarFor(idcs ...string) calendar.Calendar {
+	var calendars []calendar.Calendar
+	seen := make(map[calendar.Calendar]bool)
+	for _, idc := range idcs {
+		idcCalendars, exists := m.idcCalendars[idc]
+		if !exists {
+			idcCalendars = []calendar.Calendar{m.calendar}
+		}
+		for _, cal := range idcCalendars {
+			if !seen[cal] {
+				seen[cal] = true
+				calendars = append(calendars, cal)
+			}
+		}
+	}
+	if len(calendars) == 1 {
+		return calendars[0]
 	}
+	return calendar.NewCombinedCalendar(calendars...)
 }
 
 // IsLunarFestival checks if a given date is a lunar festival
@@ -58,7 +191,7 @@ func (m *Monitor) IsLunarFestival(date time.Time) (string, bool) {
 	return m.calendar.GetFestival(date)
 }
 
-// GetPreviousLunarFestivalDate gets the date of the same lunar festival from the previous year
+// GetPreviousLunarFestivalDate gets the date of the previous occurrence of the same lunar festival
 func (m *Monitor) GetPreviousLunarFestivalDate(currentDate time.Time, festival string) (time.Time, error) {
 	return m.calendar.GetPreviousFestivalDate(currentDate, festival)
 }
@@ -87,92 +220,278 @@ func (m *Monitor) CompareTraffic(current, historical []types.TrafficData, timeDi
 	return 0, false
 }
 
+// comparison is a historical date the current traffic is compared with
+type comparison struct {
+	period   string
+	date     time.Time
+	festival string
+}
+
+// comparisons returns the historical dates to compare currentDate with, using the festivals and day types of cal
+func (m *Monitor) comparisons(currentDate time.Time, cal calendar.Calendar) ([]comparison, error) {
+	var comparisons []comparison
+
+	// Check if current date is in festival windows, and compare with the same
+	// day of each window in previous years, highest priority festival first.
+	// Festivals without enough history, e.g. a first campaign, are not compared with.
+	compared := make(map[time.Time]bool)
+	for _, day := range cal.GetFestivalDays(currentDate) {
+		occurrences, err := cal.GetFestivalDayOccurrences(currentDate, day, m.festivalYears)
+		if err != nil {
+			m.logger.Warn("Skipping festival comparison",
+				zap.String("festival", day.String()),
+				zap.Error(err))
+			continue
+		}
+		for i, date := range occurrences {
+			// Festivals that coincided before share a historical date, compared once
+			if compared[date] {
+				continue
+			}
+			compared[date] = true
+			period := fmt.Sprintf("Previous %s", day)
+			if i > 0 {
+				period = fmt.Sprintf("%s %d years ago", day, i+1)
+			}
+			comparisons = append(comparisons, comparison{
+				period:   period,
+				date:     date,
+				festival: day.String(),
+			})
+		}
+	}
+
+	// Compare with the configured baselines
+	for _, baseline := range m.baselines {
+		date, err := baseline.Select(currentDate, cal)
+		if err != nil {
+			m.logger.Warn("Skipping baseline",
+				zap.String("period", baseline.Period),
+				zap.Error(err))
+			continue
+		}
+		// Baselines that select an already compared date, e.g. the previous day of the
+		// same type a week ago, are compared once
+		if compared[date] {
+			continue
+		}
+		compared[date] = true
+		comparisons = append(comparisons, comparison{
+			period: baseline.Period,
+			date:   date,
+		})
+	}
+
+	return comparisons, nil
+}
+
+// BaselineDate is a historical date the monitor compares a date with
+type BaselineDate struct {
+	Period   string
+	Date     time.Time
+	Festival string // the festival day compared with, empty for the configured baselines
+}
+
+// BaselineDates returns the historical dates the monitor compares currentDate with for the given IDCs
+func (m *Monitor) BaselineDates(currentDate time.Time, idcs ...string) ([]BaselineDate, error) {
+	comparisons, err := m.comparisons(currentDate, m.CalendarFor(idcs...))
+	if err != nil {
+		return nil, err
+	}
+	dates := make([]BaselineDate, len(comparisons))
+	for i, c := range comparisons {
+		dates[i] = BaselineDate{Period: c.period, Date: c.date, Festival: c.festival}
+	}
+	return dates, nil
+}
+
+// festivalNames returns the names of festival days, e.g. "春节+2", or nil for none
+func festivalNames(days []calendar.FestivalDay) []string {
+	var names []string
+	for _, day := range days {
+		names = append(names, day.String())
+	}
+	return names
+}
+
 // MonitorTraffic monitors traffic changes for different time periods
 func (m *Monitor) MonitorTraffic(module, idc string, currentDate time.Time) ([]types.Notification, error) {
 	var notifications []types.Notification
 
+	detectors, err := m.detectorsFor(module, idc)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get current data
 	currentData, err := m.dataProvider.GetData(module, idc, currentDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get current data: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrNoCurrentData, err)
 	}
 
-	// Create time series analyzer for current data
-	currentAnalyzer := analyzer.NewTimeSeriesAnalyzer(currentData)
+	// Create time series analyzer for current data, filling in missing points if configured
+	currentAnalyzer, err := m.impute(module, idc, currentDate, currentData)
+	if err != nil {
+		return nil, err
+	}
 
-	// Detect anomalies in current data
-	anomalies, err := currentAnalyzer.DetectAnomalies()
+	// Compare at the configured resolution
+	currentData, currentImputed, err := m.resampleImputed(currentAnalyzer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect anomalies: %w", err)
+		return nil, err
+	}
+
+	// Detect anomalies in current data with the detectors that need no baseline
+	var anomalies []types.Anomaly
+	for _, detector := range detectors {
+		if detector.NeedsBaseline() {
+			continue
+		}
+		found, err := detector.Detect(analyzer.DetectionInput{Current: currentData, Imputed: currentImputed})
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect anomalies with %s: %w", detector.Name(), err)
+		}
+		anomalies = append(anomalies, found...)
 	}
 
 	// Forecast future traffic
-	forecast, err := currentAnalyzer.Forecast(24) // Forecast next 24 hours
+	forecast, err := currentAnalyzer.ForecastAt(m.forecastResolution, m.forecastSteps)
 	if err != nil {
 		return nil, fmt.Errorf("failed to forecast traffic: %w", err)
 	}
 
-	// Check if current date is a lunar festival
-	if festival, isFestival := m.IsLunarFestival(currentDate); isFestival {
-		previousDate, err := m.GetPreviousLunarFestivalDate(currentDate, festival)
+	cal := m.CalendarFor(idc)
+	comparisons, err := m.comparisons(currentDate, cal)
+	if err != nil {
+		return nil, err
+	}
+
+	currentMean := calculateMean(currentData)
+	var baselines []types.BaselineResult
+	// The most relevant baseline with data, festivals first, is the daily pattern level shifts are found against
+	var shiftBaseline []types.TrafficData
+	for _, c := range comparisons {
+		historicalData, err := m.dataProvider.GetData(module, idc, c.date)
+		if err != nil {
+			// A missing baseline day leaves the others to compare with
+			m.logger.Warn("Skipping baseline without data",
+				zap.String("period", c.period),
+				zap.Time("date", c.date),
+				zap.Error(err))
+			continue
+		}
+		historicalAnalyzer, err := m.impute(module, idc, c.date, historicalData)
+		if errors.Is(err, analyzer.ErrNoObservations) {
+			// A baseline lost to an outage leaves the others to compare with
+			m.logger.Warn("Skipping baseline without observed data",
+				zap.String("period", c.period),
+				zap.Time("date", c.date))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if shiftBaseline == nil {
+			shiftBaseline = historicalAnalyzer.Data()
+		}
+		historicalData, historicalImputed, err := m.resampleImputed(historicalAnalyzer)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get previous festival date: %w", err)
+			return nil, err
+		}
+
+		// Compare with the baseline using the detectors that need one
+		var detections []types.Anomaly
+		input := analyzer.DetectionInput{
+			Current:         currentData,
+			Baseline:        historicalData,
+			Period:          c.period,
+			Imputed:         currentImputed,
+			BaselineImputed: historicalImputed,
+		}
+		for _, detector := range detectors {
+			if !detector.NeedsBaseline() {
+				continue
+			}
+			found, err := detector.Detect(input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compare with %s using %s: %w", c.period, detector.Name(), err)
+			}
+			detections = append(detections, found...)
 		}
 
-		historicalData, err := m.dataProvider.GetData(module, idc, previousDate)
+		historicalMean := calculateMean(historicalData)
+		baselines = append(baselines, types.BaselineResult{
+			Period:         c.period,
+			Date:           c.date,
+			Festival:       c.festival,
+			HistoricalMean: historicalMean,
+			Increase:       m.CalculateIncrease(currentMean, historicalMean),
+			Weight:         m.ensemble.weight(c.period),
+			Fired:          len(detections) > 0,
+			Anomalies:      detections,
+		})
+	}
+
+	// Detect persistent level shifts in current data relative to the baseline's daily pattern
+	var levelShifts []types.LevelShift
+	if shiftBaseline != nil {
+		levelShifts, err = currentAnalyzer.DetectChangePointsAgainst(shiftBaseline, analyzer.DefaultChangePointOptions())
 		if err != nil {
-			return nil, fmt.Errorf("failed to get historical data: %w", err)
-		}
-
-		if increase, significant := m.CompareTraffic(currentData, historicalData, fmt.Sprintf("Previous %s", festival)); significant {
-			notifications = append(notifications, types.Notification{
-				Module:         module,
-				IDC:            idc,
-				CurrentDate:    currentDate,
-				HistoricalDate: previousDate,
-				Period:         fmt.Sprintf("Previous %s", festival),
-				Increase:       increase,
-				CurrentMean:    calculateMean(currentData),
-				HistoricalMean: calculateMean(historicalData),
-				Festival:       festival,
-				Anomalies:      anomalies,
-				Forecast:       forecast,
-			})
+			return nil, fmt.Errorf("failed to detect level shifts: %w", err)
 		}
 	}
 
-	// Compare with regular time periods
-	timePeriods := []struct {
-		name     string
-		duration time.Duration
-	}{
-		{"1 day ago", 24 * time.Hour},
-		{"7 days ago", 7 * 24 * time.Hour},
-		{"30 days ago", 30 * 24 * time.Hour},
-		{"1 year ago", 365 * 24 * time.Hour},
+	base := types.Notification{
+		Module:             module,
+		IDC:                idc,
+		CurrentDate:        currentDate,
+		CurrentMean:        currentMean,
+		Festivals:          festivalNames(cal.GetFestivalDays(currentDate)),
+		LevelShifts:        levelShifts,
+		Forecast:           forecast,
+		ForecastResolution: m.forecastResolution,
 	}
 
-	for _, period := range timePeriods {
-		historicalDate := currentDate.Add(-period.duration)
-		historicalData, err := m.dataProvider.GetData(module, idc, historicalDate)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get historical data: %w", err)
-		}
-
-		if increase, significant := m.CompareTraffic(currentData, historicalData, period.name); significant {
-			notifications = append(notifications, types.Notification{
-				Module:         module,
-				IDC:            idc,
-				CurrentDate:    currentDate,
-				HistoricalDate: historicalDate,
-				Period:         period.name,
-				Increase:       increase,
-				CurrentMean:    calculateMean(currentData),
-				HistoricalMean: calculateMean(historicalData),
-				Anomalies:      anomalies,
-				Forecast:       forecast,
-			})
+	// Detections of the current data alone alert without a baseline period
+	if len(anomalies) > 0 {
+		m.logger.Warn("Anomalies detected in current traffic",
+			zap.Int("detections", len(anomalies)))
+		n := base
+		n.Anomalies = anomalies
+		notifications = append(notifications, n)
+	}
+
+	if m.ensemble.Mode != EnsembleNone {
+		significant, rule := m.verdict(baselines)
+		if !significant {
+			return notifications, nil
 		}
+		n := m.ensembleNotification(base, baselines, rule)
+		m.logger.Warn("Significant traffic change detected",
+			zap.String("ensemble", m.ensemble.Mode.String()),
+			zap.String("rule", rule),
+			zap.Float64("increase", n.Increase))
+		return append(notifications, n), nil
+	}
+
+	for _, b := range baselines {
+		if !b.Fired {
+			continue
+		}
+
+		m.logger.Warn("Significant traffic change detected",
+			zap.String("period", b.Period),
+			zap.Float64("increase", b.Increase),
+			zap.Int("detections", len(b.Anomalies)))
+
+		n := base
+		n.HistoricalDate = b.Date
+		n.Period = b.Period
+		n.Increase = b.Increase
+		n.HistoricalMean = b.HistoricalMean
+		n.Festival = b.Festival
+		n.Anomalies = b.Anomalies
+		notifications = append(notifications, n)
 	}
 
 	return notifications, nil
@@ -1,10 +1,17 @@
 package monitor
 
 import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/calendar"
 	"github.com/whichonezhang/traffic_monitor/internal/types"
 	"go.uber.org/zap"
 )
@@ -122,3 +129,543 @@ func TestCompareTraffic(t *testing.T) {
 		})
 	}
 }
+
+// memoryProvider serves traffic data from memory instead of CSV files
+type memoryProvider struct {
+	data map[string][]types.TrafficData
+}
+
+func newMemoryProvider() *memoryProvider {
+	return &memoryProvider{data: make(map[string][]types.TrafficData)}
+}
+
+func (p *memoryProvider) key(module, idc string, date time.Time) string {
+	return module + "_" + idc + "_" + date.Format("20060102")
+}
+
+func (p *memoryProvider) GetData(module, idc string, date time.Time) ([]types.TrafficData, error) {
+	data, exists := p.data[p.key(module, idc, date)]
+	if !exists {
+		return nil, fmt.Errorf("no data for %s", p.key(module, idc, date))
+	}
+	return data, nil
+}
+
+func (p *memoryProvider) SaveData(module, idc string, date time.Time, data []types.TrafficData) error {
+	p.data[p.key(module, idc, date)] = data
+	return nil
+}
+
+// dayOfTraffic creates one day of per-minute traffic around level with a small daily wobble
+func dayOfTraffic(date time.Time, level float64) []types.TrafficData {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	data := make([]types.TrafficData, 1440)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Requests:  level + float64(i%7) - 3,
+		}
+	}
+	return data
+}
+
+// diurnalDay creates one day of per-minute traffic following a daily cycle around level with noise
+func diurnalDay(date time.Time, level float64, seed uint64) []types.TrafficData {
+	rng := rand.New(rand.NewPCG(seed, 1))
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	data := make([]types.TrafficData, 1440)
+	for i := range data {
+		data[i] = types.TrafficData{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Requests:  level * (1 + 0.5*math.Sin(2*math.Pi*float64(i)/1440) + 0.02*rng.NormFloat64()),
+		}
+	}
+	return data
+}
+
+func TestMonitorTrafficLevelShifts(t *testing.T) {
+	// Traffic doubled all day: an increase, but not a level shift within the day
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, diurnalDay(currentDate, 2000, 1))
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.AddDate(0, 0, -days)
+		provider.SaveData("api", "us-west", date, diurnalDay(date, 1000, uint64(days)+1))
+	}
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+	assert.NoError(t, m.SetDetectors(DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.2}}))
+
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 4)
+	for _, n := range notifications {
+		assert.Empty(t, n.LevelShifts, n.Period)
+	}
+
+	// A step in the afternoon is one
+	current := diurnalDay(currentDate, 1000, 1)
+	for i := 900; i < len(current); i++ {
+		current[i].Requests *= 2
+	}
+	provider.SaveData("api", "us-west", currentDate, current)
+	notifications, err = m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, notifications) && assert.Len(t, notifications[0].LevelShifts, 1) {
+		assert.WithinDuration(t, currentDate.Add(15*time.Hour), notifications[0].LevelShifts[0].Timestamp, 5*time.Minute)
+	}
+}
+
+func TestMonitorTrafficDetectors(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	current := dayOfTraffic(currentDate, 200)
+	current[600].Requests = 2000
+	provider.SaveData("api", "us-west", currentDate, current)
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.Add(-time.Duration(days) * 24 * time.Hour)
+		provider.SaveData("api", "us-west", date, dayOfTraffic(date, 100))
+	}
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+
+	err := m.SetDetectors(
+		DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.5}},
+		DetectorConfig{Name: analyzer.DetectorZScore},
+	)
+	assert.NoError(t, err)
+
+	// The spike alerts on its own, the doubled traffic once per baseline
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 5) {
+		spike := notifications[0]
+		assert.Empty(t, spike.Period)
+		assert.True(t, spike.HistoricalDate.IsZero())
+		if assert.Len(t, spike.Anomalies, 1) {
+			assert.Equal(t, analyzer.DetectorZScore, spike.Anomalies[0].Detector)
+			assert.Equal(t, current[600].Timestamp, spike.Anomalies[0].Start)
+		}
+		for _, n := range notifications[1:] {
+			assert.NotEmpty(t, n.Period)
+			if assert.Len(t, n.Anomalies, 1) {
+				assert.Equal(t, analyzer.DetectorRatio, n.Anomalies[0].Detector)
+			}
+			assert.InDelta(t, 1.0, n.Increase, 0.1)
+		}
+	}
+
+	// A series-specific configuration without a baseline detector alerts on the current data only
+	err = m.SetSeriesDetectors("api", "us-west", DetectorConfig{Name: analyzer.DetectorZScore})
+	assert.NoError(t, err)
+	notifications, err = m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 1) {
+		assert.Empty(t, notifications[0].Period)
+		assert.NotEmpty(t, notifications[0].Anomalies)
+	}
+
+	// Without the spike nothing alerts
+	current[600].Requests = 200
+	notifications, err = m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	assert.Empty(t, notifications)
+
+	err = m.SetDetectors(DetectorConfig{Name: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestMonitorTrafficEnsemble(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 200))
+	// Only the 4-weeks-ago baseline is a quiet day, the others match today
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.Add(-time.Duration(days) * 24 * time.Hour)
+		level := 190.0
+		if days == 28 {
+			level = 100
+		}
+		provider.SaveData("api", "us-west", date, dayOfTraffic(date, level))
+	}
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+	assert.NoError(t, m.SetDetectors(DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.5}}))
+
+	// Without an ensemble the bad baseline raises its own alert
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, "same weekday 4 weeks ago", notifications[0].Period)
+	}
+
+	tests := []struct {
+		name   string
+		config EnsembleConfig
+		alert  bool
+	}{
+		{"median", EnsembleConfig{Mode: EnsembleMedian}, false},
+		{"1 of 4", EnsembleConfig{Mode: EnsembleKOfN, K: 1}, true},
+		{"2 of 4", EnsembleConfig{Mode: EnsembleKOfN, K: 2}, false},
+		{"majority", EnsembleConfig{Mode: EnsembleWeightedVote, Quorum: 0.5}, false},
+		{"weighted majority", EnsembleConfig{Mode: EnsembleWeightedVote, Quorum: 0.5, Weights: map[string]float64{"same weekday 4 weeks ago": 3}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, m.SetEnsemble(tt.config))
+			notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+			assert.NoError(t, err)
+			if !tt.alert {
+				assert.Empty(t, notifications)
+				return
+			}
+			if assert.Len(t, notifications, 1) {
+				n := notifications[0]
+				assert.Len(t, n.Baselines, 4)
+				assert.True(t, n.HistoricalDate.IsZero())
+				assert.InDelta(t, 200.0/190-1, n.Increase, 0.01)
+				for _, b := range n.Baselines {
+					assert.Equal(t, b.Period == "same weekday 4 weeks ago", b.Fired)
+				}
+			}
+		})
+	}
+
+	assert.Error(t, m.SetEnsemble(EnsembleConfig{Mode: EnsembleKOfN}))
+	assert.Error(t, m.SetEnsemble(EnsembleConfig{Mode: EnsembleWeightedVote, Quorum: 1.5}))
+}
+
+func TestBaselineSelection(t *testing.T) {
+	cal := calendar.NewLunarCalendar()
+	// Tuesday after the 2024 Dragon Boat Festival holiday (Monday June 10)
+	tuesday := time.Date(2024, 6, 11, 0, 0, 0, 0, time.Local)
+	saturday := time.Date(2024, 6, 15, 0, 0, 0, 0, time.Local)
+	festival := time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		baseline Baseline
+		current  time.Time
+		want     time.Time
+	}{
+		{"1 day ago", DaysAgo(1), tuesday, time.Date(2024, 6, 10, 0, 0, 0, 0, time.Local)},
+		{"same weekday 4 weeks ago", SameWeekdayWeeksAgo(4), tuesday, time.Date(2024, 5, 14, 0, 0, 0, 0, time.Local)},
+		{"same weekday a year ago", SameWeekdayYearAgo(), tuesday, time.Date(2023, 6, 13, 0, 0, 0, 0, time.Local)},
+		{"previous workday skips the holiday", SameDayType(14), tuesday, time.Date(2024, 6, 7, 0, 0, 0, 0, time.Local)},
+		{"previous weekend day", SameDayType(14), saturday, time.Date(2024, 6, 9, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := tt.baseline.Select(tt.current, cal)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, date)
+		})
+	}
+
+	// No earlier holiday within the look-back window
+	_, err := SameDayType(30).Select(festival, cal)
+	assert.Error(t, err)
+
+	// Make-up workdays compare with the last working day before the Spring Festival holiday
+	scheduled := calendar.NewLunarCalendar()
+	assert.NoError(t, scheduled.LoadHolidaySchedules("../../data/holidays", "CN", 2024))
+	date, err := SameDayType(14).Select(time.Date(2024, 2, 18, 0, 0, 0, 0, time.Local), scheduled)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 9, 0, 0, 0, 0, time.Local), date)
+
+	m := NewMonitor(0.5, zap.NewNop())
+	assert.NoError(t, m.SetBaselines(SameWeekdayWeeksAgo(1), SameDayType(30)))
+	comparisons, err := m.comparisons(festival, m.calendar)
+	assert.NoError(t, err)
+	// The festival comparison is kept and the holiday baseline without a match is skipped
+	if assert.Len(t, comparisons, 2) {
+		assert.Equal(t, "端午节", comparisons[0].festival)
+		assert.Equal(t, time.Date(2023, 6, 22, 0, 0, 0, 0, time.Local), comparisons[0].date)
+		assert.Equal(t, "same weekday 1 week ago", comparisons[1].period)
+	}
+
+	// Festival days can be compared with several past years
+	assert.NoError(t, m.SetFestivalYears(3))
+	comparisons, err = m.comparisons(festival, m.calendar)
+	assert.NoError(t, err)
+	if assert.Len(t, comparisons, 4) {
+		assert.Equal(t, "端午节 3 years ago", comparisons[2].period)
+		assert.Equal(t, time.Date(2021, 6, 14, 0, 0, 0, 0, time.Local), comparisons[2].date)
+	}
+	assert.Error(t, m.SetFestivalYears(0))
+
+	// Days inside a festival window compare with the same day of the window
+	comparisons, err = m.comparisons(time.Date(2026, 2, 19, 0, 0, 0, 0, time.Local), m.calendar)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, comparisons) {
+		assert.Equal(t, "Previous 春节+2", comparisons[0].period)
+		assert.Equal(t, "春节+2", comparisons[0].festival)
+		assert.Equal(t, time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local), comparisons[0].date)
+	}
+	assert.Error(t, m.SetBaselines(Baseline{Period: "broken"}))
+
+	// By default a Sunday compares with the day before and Sundays only
+	m = NewMonitor(0.5, zap.NewNop())
+	sunday := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	dates, err := m.BaselineDates(sunday)
+	assert.NoError(t, err)
+	assert.Equal(t, []BaselineDate{
+		{Period: "previous day of the same type", Date: time.Date(2024, 3, 9, 0, 0, 0, 0, time.Local)},
+		{Period: "same weekday 1 week ago", Date: time.Date(2024, 3, 3, 0, 0, 0, 0, time.Local)},
+		{Period: "same weekday 4 weeks ago", Date: time.Date(2024, 2, 11, 0, 0, 0, 0, time.Local)},
+		{Period: "1 year ago (same weekday)", Date: time.Date(2023, 3, 12, 0, 0, 0, 0, time.Local)},
+	}, dates)
+
+	// Baselines selecting the same date are compared once
+	assert.NoError(t, m.SetBaselines(SameWeekdayWeeksAgo(1), DaysAgo(7)))
+	comparisons, err = m.comparisons(sunday, m.calendar)
+	assert.NoError(t, err)
+	assert.Len(t, comparisons, 1)
+}
+
+func TestIDCCalendars(t *testing.T) {
+	m := NewMonitor(0.5, zap.NewNop())
+	assert.NoError(t, m.SetBaselines(DaysAgo(1)))
+	assert.NoError(t, m.SetIDCCalendars("us-west", calendar.NewUSCalendar()))
+	assert.Error(t, m.SetIDCCalendars("eu-central"))
+	thanksgiving := time.Date(2024, 11, 28, 0, 0, 0, 0, time.Local)
+
+	comparisons, err := m.comparisons(thanksgiving, m.CalendarFor("us-west"))
+	assert.NoError(t, err)
+	if assert.Len(t, comparisons, 2) {
+		assert.Equal(t, "Previous Thanksgiving", comparisons[0].period)
+		assert.Equal(t, time.Date(2023, 11, 23, 0, 0, 0, 0, time.Local), comparisons[0].date)
+	}
+
+	dates, err := m.BaselineDates(thanksgiving, "us-west")
+	assert.NoError(t, err)
+	if assert.Len(t, dates, 2) {
+		assert.Equal(t, BaselineDate{Period: "Previous Thanksgiving", Date: time.Date(2023, 11, 23, 0, 0, 0, 0, time.Local), Festival: "Thanksgiving"}, dates[0])
+		assert.Equal(t, BaselineDate{Period: "1 day ago", Date: thanksgiving.AddDate(0, 0, -1)}, dates[1])
+	}
+
+	// IDCs without calendars keep the lunar calendar
+	comparisons, err = m.comparisons(thanksgiving, m.CalendarFor("cn-north"))
+	assert.NoError(t, err)
+	assert.Len(t, comparisons, 1)
+
+	// A module spanning both regions sees the festivals of both
+	cal := m.CalendarFor("us-west", "cn-north")
+	assert.Contains(t, cal.Festivals(), "Thanksgiving")
+	assert.Contains(t, cal.Festivals(), "春节")
+	assert.Same(t, m.calendar, m.CalendarFor("cn-north", "cn-south"))
+
+	// Imported events compare with their previous edition
+	path := filepath.Join(t.TempDir(), "campaigns.ics")
+	ics := "BEGIN:VEVENT\nSUMMARY:Summer Sale\nDTSTART;VALUE=DATE:20230701\nDTEND;VALUE=DATE:20230708\nRRULE:FREQ=YEARLY\nEND:VEVENT\n"
+	assert.NoError(t, os.WriteFile(path, []byte(ics), 0o644))
+	assert.NoError(t, m.LoadEvents(path, "us-west", "cn-north"))
+	comparisons, err = m.comparisons(time.Date(2024, 7, 3, 0, 0, 0, 0, time.Local), m.CalendarFor("cn-north"))
+	assert.NoError(t, err)
+	if assert.Len(t, comparisons, 2) {
+		assert.Equal(t, "Summer Sale+2", comparisons[0].festival)
+		assert.Equal(t, time.Date(2023, 7, 3, 0, 0, 0, 0, time.Local), comparisons[0].date)
+	}
+	assert.Contains(t, m.CalendarFor("us-west").Festivals(), "Thanksgiving")
+	assert.Error(t, m.LoadEvents(filepath.Join(t.TempDir(), "missing.ics"), "us-west"))
+
+	// Calendars by name are shared between IDCs, CN being the lunar calendar
+	assert.NoError(t, m.SetIDCCalendarNames("us-east", "us"))
+	assert.NoError(t, m.SetIDCCalendarNames("us-central", "US"))
+	assert.NoError(t, m.SetIDCCalendarNames("eu-central", "EU", "CN"))
+	assert.Same(t, m.CalendarFor("us-east"), m.CalendarFor("us-central"))
+	assert.Equal(t, calendar.Holiday, m.CalendarFor("us-east", "us-central").DayType(thanksgiving))
+	assert.Contains(t, m.CalendarFor("eu-central").Festivals(), "Easter Monday")
+	assert.Contains(t, m.CalendarFor("eu-central").Festivals(), "春节")
+	assert.Error(t, m.SetIDCCalendarNames("ap-south", "Mars"))
+	assert.Error(t, m.SetIDCCalendarNames("ap-south"))
+}
+
+func TestFirstEdition(t *testing.T) {
+	m := NewMonitor(0.5, zap.NewNop())
+	assert.NoError(t, m.SetBaselines(DaysAgo(1)))
+	path := filepath.Join(t.TempDir(), "launch.ics")
+	ics := "BEGIN:VEVENT\nSUMMARY:Product Launch\nDTSTART;VALUE=DATE:20261020\nEND:VEVENT\n"
+	assert.NoError(t, os.WriteFile(path, []byte(ics), 0o644))
+	assert.NoError(t, m.LoadEvents(path, "us-west"))
+
+	// A one-off event has no previous edition, so only the regular baselines are compared with
+	launch := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	dates, err := m.BaselineDates(launch, "us-west")
+	assert.NoError(t, err)
+	assert.Equal(t, []BaselineDate{{Period: "1 day ago", Date: launch.AddDate(0, 0, -1)}}, dates)
+}
+
+func TestConcurrentFestivals(t *testing.T) {
+	m := NewMonitor(0.5, zap.NewNop())
+	assert.NoError(t, m.SetBaselines(DaysAgo(1)))
+	path := filepath.Join(t.TempDir(), "campaigns.ics")
+	ics := "BEGIN:VEVENT\nSUMMARY:Mooncake Sale\nDTSTART;VALUE=DATE:20230925\nPRIORITY:1\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Mooncake Sale\nDTSTART;VALUE=DATE:20240917\nPRIORITY:1\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Lantern Show\nDTSTART;VALUE=DATE:20230929\nEND:VEVENT\n" +
+		"BEGIN:VEVENT\nSUMMARY:Lantern Show\nDTSTART;VALUE=DATE:20240917\nEND:VEVENT\n"
+	assert.NoError(t, os.WriteFile(path, []byte(ics), 0o644))
+	assert.NoError(t, m.LoadEvents(path, "cn-north"))
+	midAutumn := time.Date(2024, 9, 17, 0, 0, 0, 0, time.Local)
+
+	// Each festival compares with its previous edition, the campaign first; the show
+	// coincided with the festival last year, so their comparison is made once, for the
+	// show whose calendar comes first
+	for i := 0; i < 3; i++ {
+		comparisons, err := m.comparisons(midAutumn, m.CalendarFor("cn-north"))
+		assert.NoError(t, err)
+		if assert.Len(t, comparisons, 3) {
+			assert.Equal(t, comparison{period: "Previous Mooncake Sale", date: time.Date(2023, 9, 25, 0, 0, 0, 0, time.Local), festival: "Mooncake Sale"}, comparisons[0])
+			assert.Equal(t, comparison{period: "Previous Lantern Show", date: time.Date(2023, 9, 29, 0, 0, 0, 0, time.Local), festival: "Lantern Show"}, comparisons[1])
+			assert.Equal(t, "1 day ago", comparisons[2].period)
+		}
+	}
+	assert.Equal(t, []string{"Mooncake Sale", "Lantern Show", "中秋节"}, festivalNames(m.CalendarFor("cn-north").GetFestivalDays(midAutumn)))
+}
+
+func TestMonitorModule(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 20))
+	provider.SaveData("api", "us-east", currentDate, dayOfTraffic(currentDate, 180))
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.AddDate(0, 0, -days)
+		provider.SaveData("api", "us-west", date, dayOfTraffic(date, 100))
+		provider.SaveData("api", "us-east", date, dayOfTraffic(date, 100))
+	}
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+
+	notifications, err := m.MonitorModule("api", []string{"us-east", "us-west"}, currentDate)
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 4)
+	for _, n := range notifications {
+		assert.Equal(t, "us-east,us-west", n.IDC)
+		if assert.NotNil(t, n.Shift) {
+			assert.Equal(t, types.ShiftMigration, n.Shift.Kind)
+			assert.Len(t, n.Shift.IDCs, 2)
+		}
+		assert.InDelta(t, 0, n.Increase, 0.01)
+		assert.InDelta(t, 200, n.CurrentMean, 1)
+	}
+
+	// A surge in one IDC that moves the module total is attributed to that IDC
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 100))
+	provider.SaveData("api", "us-east", currentDate, dayOfTraffic(currentDate, 300))
+	notifications, err = m.MonitorModule("api", []string{"us-east", "us-west"}, currentDate)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, notifications) && assert.Len(t, notifications[0].RootCauses, 1) {
+		cause := notifications[0].RootCauses[0]
+		assert.Equal(t, types.ShiftGrowth, notifications[0].Shift.Kind)
+		assert.Equal(t, "idc", cause.Dimension)
+		if assert.Len(t, cause.Values, 1) {
+			assert.Equal(t, "us-east", cause.Values[0].Value)
+		}
+	}
+
+	_, err = m.MonitorModule("api", nil, currentDate)
+	assert.Error(t, err)
+}
+
+func TestMonitorTrafficOutageBaseline(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 200))
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.AddDate(0, 0, -days)
+		provider.SaveData("api", "us-west", date, dayOfTraffic(date, 100))
+	}
+	// The collector was down all day a week ago
+	weekAgo := currentDate.AddDate(0, 0, -7)
+	outage := dayOfTraffic(weekAgo, 0)
+	for i := range outage {
+		outage[i].Requests = 0
+	}
+	provider.SaveData("api", "us-west", weekAgo, outage)
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+	assert.NoError(t, m.SetDetectors(DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.5}}))
+	m.SetImputation(analyzer.ImputationOptions{Method: analyzer.ImputeLinear, ZeroIsMissing: true})
+
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	var periods []string
+	for _, n := range notifications {
+		periods = append(periods, n.Period)
+	}
+	assert.Equal(t, []string{"previous day of the same type", "same weekday 4 weeks ago", "1 year ago (same weekday)"}, periods)
+}
+
+func TestMonitorTrafficMissingBaseline(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 200))
+	// Only the previous day was kept
+	provider.SaveData("api", "us-west", currentDate.AddDate(0, 0, -1), dayOfTraffic(currentDate.AddDate(0, 0, -1), 100))
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+	assert.NoError(t, m.SetDetectors(DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.5}}))
+
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 1) {
+		assert.Equal(t, "previous day of the same type", notifications[0].Period)
+	}
+
+	_, err = m.MonitorTraffic("api", "us-west", currentDate.AddDate(0, 0, 1))
+	assert.ErrorIs(t, err, ErrNoCurrentData)
+}
+
+func TestImputeFullDay(t *testing.T) {
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = newMemoryProvider()
+	m.SetImputation(analyzer.ImputationOptions{Method: analyzer.ImputeLinear})
+
+	// The first and last hours of the day are missing
+	data := dayOfTraffic(date, 100)
+	a, err := m.impute("api", "us-west", date, data[60:len(data)-60])
+	assert.NoError(t, err)
+	if assert.Len(t, a.Data(), len(data)) {
+		assert.Equal(t, date, a.Data()[0].Timestamp)
+		assert.True(t, a.Imputed()[0])
+		assert.True(t, a.Imputed()[len(data)-1])
+		assert.False(t, a.Imputed()[60])
+	}
+}
+
+func TestMonitorTrafficResolution(t *testing.T) {
+	currentDate := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	provider := newMemoryProvider()
+	provider.SaveData("api", "us-west", currentDate, dayOfTraffic(currentDate, 200))
+	for _, days := range []int{1, 7, 28, 364} {
+		date := currentDate.AddDate(0, 0, -days)
+		provider.SaveData("api", "us-west", date, dayOfTraffic(date, 100))
+	}
+
+	m := NewMonitor(0.5, zap.NewNop())
+	m.dataProvider = provider
+	assert.NoError(t, m.SetDetectors(DetectorConfig{Name: analyzer.DetectorRatio, Params: analyzer.DetectorParams{"threshold": 0.5}}))
+	assert.NoError(t, m.SetComparisonResolution(analyzer.ResampleOptions{Interval: time.Hour}))
+
+	notifications, err := m.MonitorTraffic("api", "us-west", currentDate)
+	assert.NoError(t, err)
+	if assert.Len(t, notifications, 4) {
+		// Means are of hourly totals
+		assert.InDelta(t, 200*60, notifications[0].CurrentMean, 60)
+		assert.InDelta(t, 1.0, notifications[0].Increase, 0.01)
+		if assert.Len(t, notifications[0].Anomalies, 1) {
+			assert.Equal(t, currentDate.Add(24*time.Hour), notifications[0].Anomalies[0].End)
+		}
+	}
+
+	assert.Error(t, m.SetComparisonResolution(analyzer.ResampleOptions{}))
+}
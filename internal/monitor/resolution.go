@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/whichonezhang/traffic_monitor/internal/analyzer"
+	"github.com/whichonezhang/traffic_monitor/internal/types"
+)
+
+// SetComparisonResolution resamples current and historical data before detection and
+// comparison, e.g. analyzer.ResampleOptions{Interval: 5 * time.Minute, Aggregate: analyzer.AggregateMean}.
+// Means in notifications are then means of the resampled buckets.
+func (m *Monitor) SetComparisonResolution(opts analyzer.ResampleOptions) error {
+	if opts.Interval <= 0 {
+		return fmt.Errorf("invalid comparison resolution %s", opts.Interval)
+	}
+	m.resolution = &opts
+	return nil
+}
+
+// resample converts data to the comparison resolution, if one is set
+func (m *Monitor) resample(data []types.TrafficData) ([]types.TrafficData, error) {
+	if m.resolution == nil {
+		return data, nil
+	}
+	resampled, err := analyzer.ResampleWith(data, *m.resolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resample data: %w", err)
+	}
+	return resampled, nil
+}
+
+// resampleImputed converts the data of an analyzer to the comparison resolution, if one
+// is set. Buckets holding only imputed points stay marked as imputed.
+func (m *Monitor) resampleImputed(a *analyzer.TimeSeriesAnalyzer) ([]types.TrafficData, []bool, error) {
+	if m.resolution == nil {
+		return a.Data(), a.Imputed(), nil
+	}
+	resampled, err := a.ResampleWith(*m.resolution)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resample data: %w", err)
+	}
+	return resampled.Data(), resampled.Imputed(), nil
+}
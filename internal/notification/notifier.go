@@ -3,7 +3,9 @@ package notification
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/whichonezhang/traffic_monitor/internal/calendar"
 	"github.com/whichonezhang/traffic_monitor/internal/types"
 )
 
@@ -34,46 +36,165 @@ func formatNotification(n types.Notification) string {
 	// Write header
 	if n.Festival != "" {
 		message.WriteString(fmt.Sprintf(
-			"Traffic Alert for %s Festival\n",
-			n.Festival))
+			"Traffic Alert for %s\n",
+			calendar.DisplayName(n.Festival)))
 	} else {
 		message.WriteString("Traffic Alert\n")
 	}
+	// Concurrent festivals, e.g. a campaign during a holiday
+	if len(n.Festivals) > 1 {
+		names := make([]string, len(n.Festivals))
+		for i, festival := range n.Festivals {
+			names[i] = calendar.DisplayName(festival)
+		}
+		message.WriteString(fmt.Sprintf("Festivals: %s\n", strings.Join(names, ", ")))
+	}
 
 	// Write basic information
 	message.WriteString(fmt.Sprintf(
 		"Module: %s\n"+
 			"IDC: %s\n"+
-			"Current Date: %s\n"+
-			"Historical Date: %s\n"+
-			"Period: %s\n"+
-			"Increase: %.2f%%\n"+
-			"Current Mean: %.2f\n"+
-			"Historical Mean: %.2f\n",
+			"Current Date: %s\n",
 		n.Module,
 		n.IDC,
-		n.CurrentDate.Format("2006-01-02"),
-		n.HistoricalDate.Format("2006-01-02"),
-		n.Period,
-		n.Increase*100,
-		n.CurrentMean,
-		n.HistoricalMean))
+		n.CurrentDate.Format("2006-01-02")))
+	// Ensemble verdicts combine several historical dates, listed under Baselines
+	if !n.HistoricalDate.IsZero() {
+		message.WriteString(fmt.Sprintf("Historical Date: %s\n", n.HistoricalDate.Format("2006-01-02")))
+	}
+	// Detections of the current data alone have no baseline to compare with
+	if n.Period == "" {
+		message.WriteString(fmt.Sprintf("Current Mean: %.2f\n", n.CurrentMean))
+	} else {
+		message.WriteString(fmt.Sprintf(
+			"Period: %s\n"+
+				"Increase: %.2f%%\n"+
+				"Current Mean: %.2f\n"+
+				"Historical Mean: %.2f\n",
+			n.Period,
+			n.Increase*100,
+			n.CurrentMean,
+			n.HistoricalMean))
+	}
+
+	// Write the contribution of each baseline to an ensemble verdict
+	if len(n.Baselines) > 0 {
+		message.WriteString("\nBaselines:\n")
+		for _, b := range n.Baselines {
+			verdict := "normal"
+			if b.Fired {
+				verdict = fmt.Sprintf("fired (%d detections)", len(b.Anomalies))
+			}
+			message.WriteString(fmt.Sprintf("- %s (%s): mean %.2f, %+.1f%%, weight %.2f, %s\n",
+				b.Period, b.Date.Format("2006-01-02"), b.HistoricalMean, b.Increase*100, b.Weight, verdict))
+		}
+	}
+
+	// Write the module total and per-IDC changes of a module-level notification
+	if n.Shift != nil {
+		message.WriteString(fmt.Sprintf("\nTraffic Shift: %s (total %.2f -> %.2f, %+.1f%%)\n",
+			n.Shift.Kind, n.Shift.Total.BaselineMean, n.Shift.Total.CurrentMean, n.Shift.Total.Change*100))
+		for _, idc := range n.Shift.IDCs {
+			message.WriteString(fmt.Sprintf("- %s: %.2f -> %.2f (%+.1f%%)\n",
+				idc.IDC, idc.BaselineMean, idc.CurrentMean, idc.Change*100))
+		}
+		for _, pair := range n.Shift.AntiCorrelated {
+			message.WriteString(fmt.Sprintf("- %s and %s moved in opposite directions (correlation %.2f)\n",
+				pair.A, pair.B, pair.Coefficient))
+		}
+	}
+
+	// Write the dimension values that explain the change
+	if len(n.RootCauses) > 0 {
+		message.WriteString("\nRoot Causes:\n")
+		for _, cause := range n.RootCauses {
+			for _, v := range cause.Values {
+				message.WriteString(fmt.Sprintf("- %s=%s: %.2f -> %.2f, explains %.0f%% of the change (surprise %.3f)\n",
+					cause.Dimension, v.Value, v.Expected, v.Actual, v.ExplanatoryPower*100, v.Surprise))
+			}
+		}
+	}
 
 	// Write anomaly information
 	if len(n.Anomalies) > 0 {
 		message.WriteString("\nDetected Anomalies:\n")
-		for _, idx := range n.Anomalies {
-			message.WriteString(fmt.Sprintf("- Anomaly at index %d\n", idx))
+		for _, anomaly := range n.Anomalies {
+			message.WriteString("- ")
+			if anomaly.Detector != "" {
+				message.WriteString(fmt.Sprintf("[%s] ", anomaly.Detector))
+			}
+			message.WriteString(fmt.Sprintf("%s, %+.0f%% vs expected (observed %.2f, expected %.2f, score %.1f, %s)",
+				formatRange(anomaly.Start, anomaly.End),
+				anomaly.Deviation()*100,
+				anomaly.Observed,
+				anomaly.Expected,
+				anomaly.Score,
+				anomaly.Severity))
+			if anomaly.Rule != "" {
+				message.WriteString(fmt.Sprintf(" rule: %s", anomaly.Rule))
+			}
+			message.WriteString("\n")
+		}
+	}
+
+	// Write level shift information
+	if len(n.LevelShifts) > 0 {
+		message.WriteString("\nDetected Level Shifts:\n")
+		for _, shift := range n.LevelShifts {
+			change := 0.0
+			if shift.Before != 0 {
+				change = (shift.After - shift.Before) / shift.Before
+			}
+			message.WriteString(fmt.Sprintf("- %s: %.2f -> %.2f (%+.1f%%)\n",
+				shift.Timestamp.Format("2006-01-02 15:04"), shift.Before, shift.After, change*100))
 		}
 	}
 
 	// Write forecast information
 	if len(n.Forecast) > 0 {
-		message.WriteString("\nTraffic Forecast (next 24 hours):\n")
-		for i, value := range n.Forecast {
-			message.WriteString(fmt.Sprintf("- Hour %d: %.2f\n", i+1, value))
+		horizon := n.ForecastResolution * time.Duration(len(n.Forecast))
+		message.WriteString(fmt.Sprintf("\nTraffic Forecast (next %s, per %s):\n",
+			formatDuration(horizon), formatDuration(n.ForecastResolution)))
+		for _, point := range n.Forecast {
+			message.WriteString(fmt.Sprintf("- %s: %.2f\n", point.Timestamp.Format("2006-01-02 15:04"), point.Value))
 		}
 	}
 
 	return message.String()
 }
+
+// formatRange renders a time range as "15:04–15:19", adding dates when it spans days
+func formatRange(start, end time.Time) string {
+	// A range ending exactly at midnight still belongs to the start day
+	last := end.Add(-time.Nanosecond)
+	if start.Year() == last.Year() && start.YearDay() == last.YearDay() {
+		return fmt.Sprintf("%s–%s", start.Format("15:04"), end.Format("15:04"))
+	}
+	return fmt.Sprintf("%s–%s", start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+}
+
+// formatDuration renders a duration in the largest whole unit, e.g. "24 hours" or "1 minute"
+func formatDuration(d time.Duration) string {
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if d >= unit.size && d%unit.size == 0 {
+			// Prefer hours for exactly one day so "next 24 hours" reads naturally
+			if unit.name == "day" && d == unit.size {
+				continue
+			}
+			count := int(d / unit.size)
+			if count == 1 {
+				return fmt.Sprintf("1 %s", unit.name)
+			}
+			return fmt.Sprintf("%d %ss", count, unit.name)
+		}
+	}
+	return d.String()
+}
@@ -0,0 +1,124 @@
+package types
+
+import "time"
+
+// LevelShift represents a persistent change in the traffic level
+type LevelShift struct {
+	Timestamp time.Time // first point at the new level
+	Before    float64   // mean level of the segment before the change
+	After     float64   // mean level of the segment after the change
+}
+
+// Severity grades how far an anomaly deviates from the expected traffic
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String returns the lower-case name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// Anomaly represents a contiguous time range of abnormal traffic
+type Anomaly struct {
+	Start    time.Time // timestamp of the first abnormal point
+	End      time.Time // end of the interval of the last abnormal point
+	Observed float64   // mean observed value over the range
+	Expected float64   // mean expected value over the range
+	Score    float64   // largest signed test statistic in the range, usually in standard deviations
+	Detector string    // name of the detector that reported the anomaly
+	Rule     string    // control-chart rules that fired, comma-separated, if any
+	Severity Severity
+}
+
+// Deviation returns the relative difference between observed and expected traffic
+func (a Anomaly) Deviation() float64 {
+	if a.Expected == 0 {
+		return 0
+	}
+	return (a.Observed - a.Expected) / a.Expected
+}
+
+// ShiftKind classifies how the traffic of a module changed across its IDCs
+type ShiftKind int
+
+const (
+	ShiftNone      ShiftKind = iota // no significant change
+	ShiftMigration                  // traffic moved between IDCs while the module total held
+	ShiftGrowth                     // the module total grew
+	ShiftDecline                    // the module total dropped
+	ShiftLocal                      // some IDCs changed without a matching change elsewhere or in the total
+)
+
+// String returns the lower-case name of the shift kind
+func (k ShiftKind) String() string {
+	switch k {
+	case ShiftNone:
+		return "none"
+	case ShiftMigration:
+		return "migration"
+	case ShiftGrowth:
+		return "growth"
+	case ShiftDecline:
+		return "decline"
+	case ShiftLocal:
+		return "local"
+	default:
+		return "unknown"
+	}
+}
+
+// IDCChange is the change of the mean traffic of one IDC, or of the module total
+type IDCChange struct {
+	IDC          string
+	CurrentMean  float64
+	BaselineMean float64
+	Change       float64 // relative change, 0.5 = +50%
+}
+
+// IDCCorrelation is the correlation of the excess traffic of two IDCs over their baselines
+type IDCCorrelation struct {
+	A, B        string
+	Coefficient float64
+}
+
+// TrafficShift describes the change of a module's traffic across all its IDCs
+type TrafficShift struct {
+	Kind  ShiftKind
+	Total IDCChange   // module total, IDC is empty
+	IDCs  []IDCChange // per IDC, sorted by name
+	// Net is the net change of the total divided by the sum of absolute IDC changes:
+	// near 0 when traffic only moved between IDCs, 1 when all IDCs moved together
+	Net            float64
+	AntiCorrelated []IDCCorrelation // IDC pairs whose excess traffic moved in opposite directions
+}
+
+// RootCause is a dimension whose values explain a change of the total traffic
+type RootCause struct {
+	Dimension        string           // e.g. "idc"
+	Values           []RootCauseValue // values responsible for the change, most surprising first
+	Surprise         float64          // sum of the surprise of the values
+	ExplanatoryPower float64          // share of the total change explained by the values
+}
+
+// RootCauseValue is one dimension value contributing to a change
+type RootCauseValue struct {
+	Value            string
+	Expected         float64 // baseline mean traffic of the value
+	Actual           float64 // current mean traffic of the value
+	Surprise         float64 // Jensen-Shannon divergence between its expected and actual share of the total
+	ExplanatoryPower float64 // its change divided by the change of the total
+}
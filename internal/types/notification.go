@@ -13,6 +13,28 @@ type Notification struct {
 	CurrentMean    float64
 	HistoricalMean float64
 	Festival       string
-	Anomalies      []int
-	Forecast       []float64
+	Festivals      []string // all festival days of the current date, highest priority first
+	Anomalies      []Anomaly
+	LevelShifts    []LevelShift
+	Forecast       []ForecastPoint
+	// ForecastResolution is the interval covered by each forecast point
+	ForecastResolution time.Duration
+	// Baselines lists the contribution of every baseline to an ensemble verdict
+	Baselines []BaselineResult
+	// Shift describes the change across all IDCs of a module-level notification
+	Shift *TrafficShift
+	// RootCauses ranks the dimensions that explain a module-level change
+	RootCauses []RootCause
+}
+
+// BaselineResult is the outcome of comparing the current traffic with one baseline
+type BaselineResult struct {
+	Period         string
+	Date           time.Time
+	Festival       string
+	HistoricalMean float64
+	Increase       float64
+	Weight         float64
+	Fired          bool      // at least one baseline detector flagged the comparison
+	Anomalies      []Anomaly // anomalies found by the baseline detectors
 }
@@ -7,3 +7,9 @@ type TrafficData struct {
 	Timestamp time.Time
 	Requests  float64
 }
+
+// ForecastPoint represents a forecast value for the interval starting at Timestamp
+type ForecastPoint struct {
+	Timestamp time.Time
+	Value     float64
+}